@@ -0,0 +1,77 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// Adapter generates a func type which implements an interface with a single method
+// by calling itself, like http.HandlerFunc.
+type Adapter struct {
+	name string
+
+	typeGenerator *TypeDecl
+	funcGenerator *Func
+}
+
+func NewAdapter(name string, interFace *types.Interface) (*Adapter, error) {
+	if interFace.NumMethods() != 1 {
+		return nil, fmt.Errorf("adapter requires an interface with a single method, but it has %d methods", interFace.NumMethods())
+	}
+	method := interFace.Method(0)
+	sig := method.Type().(*types.Signature)
+	typeGenerator := NewTypeDecl(name, unnamedSignature(sig))
+
+	params, err := NewFieldListFromType(sig.Params())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+	}
+	params = params.WithNames("arg")
+	results, err := NewFieldListFromType(sig.Results())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+	}
+
+	used := make(map[string]bool)
+	for _, fl := range []FieldList{params, results} {
+		for _, field := range fl {
+			used[field.Name()] = true
+		}
+	}
+	funcGenerator := NewFunc(method.Name(), params, results, typeGenerator, uniqueName("f", used), sig.Variadic())
+	funcGenerator.ValueReceiver()
+	funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		args := fn.Params().Format(FormatInputParams)
+		if fn.Variadic() {
+			args = fn.Params().Format(FormatInputParamsWithVariadic)
+		}
+		if fn.Results().Len() == 0 {
+			fmt.Fprintln(w, fn.RecvName()+args)
+		} else {
+			fmt.Fprintln(w, `return `+fn.RecvName()+args)
+		}
+		return nil
+	})
+
+	return &Adapter{
+		name:          name,
+		typeGenerator: typeGenerator,
+		funcGenerator: funcGenerator,
+	}, nil
+}
+
+func (a *Adapter) Name() string {
+	return a.name
+}
+
+func (a *Adapter) WriteTo(w io.Writer) error {
+	if err := a.typeGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate type: %w", err)
+	}
+	fmt.Fprintln(w)
+	if err := a.funcGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate func: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+const verifyPkgPath = "github.com/theoden9014/simplemock/verify"
+
+// WithAssert records the call site of every call, and generates AssertXxxCalls and AssertXxxCalledWith
+// which report a failure with the call sites and a diff of arguments. It implies WithCallLog.
+func WithAssert() Option {
+	return func(m *SimpleMock) {
+		m.callLog = true
+		m.assert = true
+	}
+}
+
+func (m *SimpleMock) addAssert() error {
+	tb := NewNamedType("testing", "TB", types.NewInterfaceType(nil, nil))
+	for _, method := range m.methods {
+		method := method
+		callers := func(w io.Writer, recvName string) {
+			fmt.Fprintln(w, `calls := `+recvName+`.`+method.name+`Calls()`)
+			fmt.Fprintln(w, `callers := make([]string, len(calls))`)
+			fmt.Fprintln(w, `for j, c := range calls {`)
+			fmt.Fprintln(w, `callers[j] = c.Caller`)
+			fmt.Fprintln(w, `}`)
+		}
+		methodName := m.name + `.` + method.name
+
+		count := NewFunc(m.helperName(`Assert`+method.name+`Calls`), FieldList{NewField("tb", tb), NewField("n", types.Typ[types.Int])}, FieldList{}, m.structGenerator, "m", false)
+		count.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			fmt.Fprintln(w, `tb.Helper()`)
+			callers(w, fn.RecvName())
+			fmt.Fprintln(w, `verify.Count(tb, "`+methodName+`", callers, n)`)
+			return nil
+		})
+		m.funcGenerators = append(m.funcGenerators, count)
+
+		if method.params.Len() == 0 {
+			continue
+		}
+		used := map[string]bool{"m": true, "tb": true, "i": true, "j": true, "c": true, "calls": true, "callers": true, "args": true}
+		wantParams := FieldList{NewField("tb", tb), NewField("i", types.Typ[types.Int])}
+		for _, param := range method.params {
+			name := uniqueName(param.Name(), used)
+			used[name] = true
+			wantParams = append(wantParams, NewField(name, param.Type()))
+		}
+		calledWith := NewFunc(m.helperName(`Assert`+method.name+`CalledWith`), wantParams, FieldList{}, m.structGenerator, "m", false)
+		calledWith.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			fmt.Fprintln(w, `tb.Helper()`)
+			callers(w, fn.RecvName())
+			fmt.Fprintln(w, `var args []verify.Arg`)
+			fmt.Fprintln(w, `if i >= 0 && i < len(calls) {`)
+			var elems []string
+			fields := method.call.FieldList()
+			for k, param := range method.params {
+				elems = append(elems, `{Name: "`+param.Name()+`", Got: calls[i].`+fields.At(k).Name()+`, Want: `+wantParams.At(k+2).Name()+`}`)
+			}
+			fmt.Fprintln(w, `args = []verify.Arg{`+strings.Join(elems, `, `)+`}`)
+			fmt.Fprintln(w, `}`)
+			fmt.Fprintln(w, `verify.Args(tb, "`+methodName+`", callers, i, args)`)
+			return nil
+		})
+		m.funcGenerators = append(m.funcGenerators, calledWith)
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package simplemock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/types"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Cache records keys of generated files, which are hashes of method sets of the types,
+// the generator and the options, to skip generation of files whose key is not changed.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	// Key is the key of the generated file.
+	Key string `json:"key"`
+	// Sum is the hash of the written file, which must be untouched to skip it.
+	Sum string `json:"sum"`
+}
+
+// DefaultCachePath returns simplemock/cache.json in the user cache directory,
+// which is $XDG_CACHE_HOME on Linux.
+func DefaultCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "simplemock", "cache.json"), nil
+}
+
+// OpenCache reads the cache file of path, which may not exist.
+// Paths of generated files are recorded relative to the cache file, so that it can be in a repository.
+func OpenCache(path string) (*Cache, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{path: path, entries: make(map[string]cacheEntry)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &c.entries); err != nil {
+		return nil, fmt.Errorf("read cache %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// entryPath returns the path of the generated file relative to the cache file, and the absolute path.
+func (c *Cache) entryPath(path string) (string, string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", "", err
+	}
+	rel, err := filepath.Rel(filepath.Dir(c.path), path)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.ToSlash(rel), path, nil
+}
+
+// Lookup reports whether the file of path was generated with key, and is untouched since then.
+func (c *Cache) Lookup(path, key string) bool {
+	name, path, err := c.entryPath(path)
+	if err != nil {
+		return false
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if !ok || entry.Key != key {
+		return false
+	}
+	sum, err := fileSum(path)
+	return err == nil && sum == entry.Sum
+}
+
+// Store records that the file of path is generated with key.
+func (c *Cache) Store(path, key string) error {
+	name, path, err := c.entryPath(path)
+	if err != nil {
+		return err
+	}
+	sum, err := fileSum(path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[name] = cacheEntry{Key: key, Sum: sum}
+	return nil
+}
+
+// Save writes the cache to the file.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	b, err := json.MarshalIndent(c.entries, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// the file is replaced at once, since other processes may read it
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+var (
+	generatorSumOnce sync.Once
+	generatorSum     string
+)
+
+// generatorVersion returns the hash of the running executable, which changes with the generator.
+func generatorVersion() string {
+	generatorSumOnce.Do(func() {
+		path, err := os.Executable()
+		if err == nil {
+			generatorSum, err = fileSum(path)
+		}
+		if err != nil {
+			generatorSum = "unknown"
+		}
+	})
+	return generatorSum
+}
+
+// cacheKey is the key of a generated file, which is computed from the types generated into it.
+type cacheKey struct {
+	types []string
+}
+
+// add adds the type generated from the named type, whose method set is qualified by
+// import paths, with underlying types of named types which it refers to for their zero values.
+func (k *cacheKey) add(name string, typ types.Type) {
+	qualifier := func(pkg *types.Package) string { return pkg.Path() }
+	s := name + " " + types.TypeString(typ, qualifier)
+	var underlyings []string
+	visitTypes(typ, func(t types.Type) {
+		if named, ok := t.(*types.Named); ok {
+			underlyings = append(underlyings, types.TypeString(named, qualifier)+" "+types.TypeString(named.Underlying(), qualifier))
+		}
+	})
+	sort.Strings(underlyings)
+	for _, u := range underlyings {
+		s += "\n\t" + u
+	}
+	k.types = append(k.types, s)
+}
+
+// sum returns the hash of the types, the generator and the options of the file.
+func (k *cacheKey) sum(opts Options, file GeneratedFile) string {
+	// options which do not change the generated code are not a part of the key
+	opts.Patterns, opts.Dir, opts.Output, opts.Parallelism, opts.Cache = nil, "", "", 0, nil
+	b, err := json.Marshal(struct {
+		Version string
+		Options Options
+		Package string
+		Types   []string
+	}{generatorVersion(), opts, file.Package, k.types})
+	if err != nil {
+		return ""
+	}
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
@@ -1,12 +1,12 @@
 package simplemock
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"go/ast"
-	"go/types"
 	"io"
 	"os"
+	"strings"
 )
 
 type Command struct {
@@ -22,12 +22,44 @@ const (
 func (c *Command) Run(args ...string) int {
 	flags := flag.NewFlagSet("simplemockgen", flag.ContinueOnError)
 	var (
-		outpath string
-		pkgname string
+		outpath   string
+		pkgname   string
+		style     string
+		feature   string
+		extract   string
+		tmpl      string
+		emit      string
+		plugin    string
+		syntax    bool
+		dir       string
+		tags      string
+		goos      string
+		goarch    string
+		tests     bool
+		gobuild   bool
+		workers   int
+		cache     bool
+		cacheFile string
 	)
 	flags.SetOutput(c.Stderr)
-	flags.StringVar(&outpath, "out", "", "output file, default output to stdout")
+	flags.BoolVar(&cache, "cache", false, "skip generation of output files whose types, generator and options are not changed since the last generation")
+	flags.StringVar(&cacheFile, "cachefile", "", "cache file of -cache such as a file in the repository, default simplemock/cache.json in the user cache directory")
+	flags.StringVar(&dir, "dir", "", "directory in which packages are loaded, default the current directory")
+	flags.StringVar(&tags, "tags", "", "comma separated list of build tags of loaded files")
+	flags.StringVar(&goos, "goos", "", "GOOS of loaded files, default the host")
+	flags.StringVar(&goarch, "goarch", "", "GOARCH of loaded files, default the host")
+	flags.BoolVar(&tests, "tests", false, "generate from types declared in test files into _test.go files of -out")
+	flags.BoolVar(&gobuild, "gobuild", false, "emit a //go:build line of -tags, -goos and -goarch in the output")
+	flags.StringVar(&outpath, "out", "", "output file, which is relative to the directory of each package, default output to stdout")
+	flags.IntVar(&workers, "parallel", 0, "number of packages generated in parallel, default GOMAXPROCS")
 	flags.StringVar(&pkgname, "pkgname", "", "output package name for mock")
+	flags.StringVar(&extract, "extract", "", "comma separated list of concrete types to extract an interface of the exported method set from, instead of interfaces")
+	flags.StringVar(&feature, "features", "", "comma separated list of optional features of mocks ("+strings.Join(featureNames(), ", ")+")")
+	flags.StringVar(&emit, "emit", "code", "kind of the output, code or model which emits models of types as JSON")
+	flags.StringVar(&tmpl, "template", "", "text/template file to render with models of types instead of styles")
+	flags.StringVar(&plugin, "plugins", "", "comma separated list of registered plugins of mocks")
+	flags.BoolVar(&syntax, "syntax", false, "generate from the syntax alone without type-checking the package")
+	flags.StringVar(&style, "style", "mock", "comma separated list of generated styles ("+strings.Join(styleNames(), ", ")+")")
 	flags.Usage = func() {
 		fmt.Fprintf(c.Stderr, "Usage: %s [options...] path1, path2, ...\n", os.Args[0])
 		flags.PrintDefaults()
@@ -38,78 +70,84 @@ func (c *Command) Run(args ...string) int {
 		return StatusErr
 	}
 
-	// default config
-	conf := struct {
-		outpath string
-		output  io.Writer
-		pkgname string
-	}{
-		outpath: outpath,
-		output:  c.Stdout,
-		pkgname: pkgname,
+	var fileCache *Cache
+	if cache || len(cacheFile) != 0 {
+		if len(cacheFile) == 0 {
+			path, err := DefaultCachePath()
+			if err != nil {
+				c.errorf("cache: %w", err)
+				return StatusErr
+			}
+			cacheFile = path
+		}
+		var err error
+		if fileCache, err = OpenCache(cacheFile); err != nil {
+			c.errorf("cache: %w", err)
+			return StatusErr
+		}
 	}
 
-	patterns := flags.Args()
-
-	gofile := NewGoFile()
+	files, err := Generate(context.Background(), Options{
+		Patterns:        flags.Args(),
+		Extract:         strings.Split(extract, ","),
+		Styles:          strings.Split(style, ","),
+		Features:        strings.Split(feature, ","),
+		Plugins:         strings.Split(plugin, ","),
+		PackageName:     pkgname,
+		Output:          outpath,
+		Template:        tmpl,
+		Emit:            emit,
+		Dir:             dir,
+		Tags:            strings.Split(tags, ","),
+		GOOS:            goos,
+		GOARCH:          goarch,
+		Tests:           tests,
+		BuildConstraint: gobuild,
+		Parallelism:     workers,
+		Cache:           fileCache,
+		Syntax:          syntax,
+	})
 
-	err := load(patterns, func(pkgname string, node ast.Node, info typeInfo, err error) error {
-		if err != nil {
-			return err
+	// files of packages without errors are written even if the others have errors
+	for _, file := range files {
+		for _, d := range file.Diagnostics {
+			c.error(d)
 		}
-		if len(conf.pkgname) == 0 {
-			conf.pkgname = pkgname
+		if file.Cached {
+			continue
 		}
-		err = walk(node, info, func(iface string, ifaceType *types.Interface, err error) error {
-			if err != nil {
-				return err
-			}
-			mockname := iface + "Mock"
-			mock, err := NewSimpleMock(mockname, ifaceType)
-			if err != nil {
-				return fmt.Errorf("SimpleMock: %w", err)
+		if err := c.write(file); err != nil {
+			c.errorf("write source code: %w", err)
+			return StatusErr
+		}
+		if fileCache != nil && len(file.CacheKey) != 0 {
+			if err := fileCache.Store(file.Path, file.CacheKey); err != nil {
+				c.errorf("cache: %w", err)
+				return StatusErr
 			}
-			return mock.WriteTo(gofile)
-		})
-		return err
-	})
-	if err != nil {
-		c.error(err)
-		return StatusErr
-	}
-
-	gofile.Package = conf.pkgname
-	if err := gofile.Generate(); err != nil {
-		c.errorf("generate source code: %w", err)
-	}
-	if err := gofile.Format(); err != nil {
-		c.errorf("format source code: %w", err)
-	}
-	if err := gofile.Check(); err != nil {
-		c.errorf("check source code: %w", err)
+		}
 	}
-
-	if len(conf.outpath) == 0 {
-		if _, err := io.Copy(conf.output, gofile); err != nil {
-			c.errorf("write source code: %w", err)
+	if fileCache != nil {
+		if err := fileCache.Save(); err != nil {
+			c.errorf("cache: %w", err)
 			return StatusErr
 		}
-		return StatusOK
 	}
-	f, err := os.OpenFile(conf.outpath,
-		os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		c.error(err)
 		return StatusErr
 	}
-	defer f.Close()
+	return StatusOK
+}
 
-	if _, err := io.Copy(f, gofile); err != nil {
-		c.errorf("write source code: %w", err)
-		return StatusErr
+// write writes the file to its path, or to Stdout if the path is empty.
+// The file of the path is replaced, since the cache records the sum of the whole file.
+func (c *Command) write(file GeneratedFile) error {
+	if len(file.Path) == 0 {
+		_, err := c.Stdout.Write(file.Source)
+		return err
 	}
-
-	return StatusOK
+	return os.WriteFile(file.Path, file.Source, 0644)
 }
 
 func (c *Command) errorf(format string, a ...interface{}) {
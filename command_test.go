@@ -0,0 +1,148 @@
+package simplemock
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommand_Run(t *testing.T) {
+	files := map[string]string{
+		"go.mod":       "module example.com/util\n\ngo 1.18\n",
+		"x.go":         "package util\n\ntype Closer interface {\n\tClose() error\n}\n",
+		"y.go":         "//go:build integration\n\npackage util\n\ntype Store interface {\n\tLoad() error\n}\n",
+		"z_windows.go": "package util\n\ntype Service interface {\n\tStop() error\n}\n",
+		"z_arm64.go":   "package util\n\ntype Register interface {\n\tFlush()\n}\n",
+	}
+	tests := []struct {
+		name       string
+		args       []string
+		want       string
+		wantStatus int
+	}{
+		{
+			name: "build tags and platform",
+			args: []string{"-style", "nop", "-tags", "integration", "-goos", "windows", "-goarch", "arm64", "-gobuild", "."},
+			want: `//go:build integration && windows && arm64
+
+package util
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+
+type NopStore struct {
+}
+
+func (NopStore) Load() error {
+	return nil
+}
+
+type NopRegister struct {
+}
+
+func (NopRegister) Flush() {
+}
+
+type NopService struct {
+}
+
+func (NopService) Stop() error {
+	return nil
+}
+`,
+		},
+		{
+			name: "other platform",
+			args: []string{"-style", "nop", "-goos", "plan9", "-goarch", "386", "."},
+			want: `package util
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			c := &Command{Stdout: stdout, Stderr: stderr}
+			if status := c.Run(append([]string{"-dir", dir}, tt.args...)...); status != tt.wantStatus {
+				t.Fatalf("Run() = %d, want %d, stderr:\n%s", status, tt.wantStatus, stderr)
+			}
+			if diff := cmp.Diff(tt.want, stdout.String()); diff != "" {
+				t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCommand_Run_Cache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "x.go")
+	out := filepath.Join(dir, "mock.go")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// run runs the command, and returns the output file and its modification time
+	run := func() (string, time.Time) {
+		t.Helper()
+		stderr := &bytes.Buffer{}
+		c := &Command{Stdout: &bytes.Buffer{}, Stderr: stderr}
+		args := []string{"-style", "nop", "-out", out, "-cachefile", filepath.Join(dir, "cache.json"), src}
+		if status := c.Run(args...); status != StatusOK {
+			t.Fatalf("Run() = %d, want %d, stderr:\n%s", status, StatusOK, stderr)
+		}
+		b, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b), info.ModTime()
+	}
+
+	// the output of the last generation is replaced
+	writeFile(out, "package util\n\ntype NopGetter struct{}\n")
+	writeFile(src, "package util\n\ntype Getter interface {\n\tGet(key string) string\n}\n")
+	run()
+	writeFile(src, "package util\n\ntype Getter interface {\n\tGet(key string) int\n}\n")
+	got, modTime := run()
+	want := `package util
+
+type NopGetter struct {
+}
+
+func (NopGetter) Get(key string) int {
+	return 0
+}
+`
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Run() output mismatch (-want +got):\n%s", diff)
+	}
+	// the output is cached with its sum
+	if got, gotModTime := run(); got != want || !gotModTime.Equal(modTime) {
+		t.Errorf("Run() without changes rewrote the output:\n%s", got)
+	}
+}
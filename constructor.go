@@ -0,0 +1,86 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// WithConstructor generates a constructor which takes functional options,
+// and options to set a stub or fixed results of each method.
+func WithConstructor() Option {
+	return func(m *SimpleMock) {
+		m.constructor = true
+	}
+}
+
+// addConstructor adds declarations of the mock option type, the constructor and options.
+//
+//	type ReaderMockOption func(*ReaderMock)
+//	func NewReaderMock(opts ...ReaderMockOption) *ReaderMock
+//	func WithReaderRead(f func(p []byte) (n int, err error)) ReaderMockOption
+//	func WithReaderReadReturns(n int, err error) ReaderMockOption
+func (m *SimpleMock) addConstructor() error {
+	mockType := types.NewPointer(NewNamedType("", m.name, m.structGenerator.Type()))
+	optionName := m.name + `Option`
+	optionType := NewNamedType("", optionName, types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", mockType)), nil, false))
+	m.declGenerators = append(m.declGenerators, NewTypeDecl(optionName, optionType.Underlying()))
+
+	constructor := NewFunc(`New`+m.name,
+		FieldList{NewField("opts", types.NewSlice(optionType))},
+		FieldList{NewField("", mockType)}, nil, "", true)
+	constructor.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		fmt.Fprintln(w, `m := &`+m.name+`{}`)
+		fmt.Fprintln(w, `for _, opt := range opts {`)
+		fmt.Fprintln(w, `opt(m)`)
+		fmt.Fprintln(w, `}`)
+		fmt.Fprintln(w, `return m`)
+		return nil
+	})
+	m.declGenerators = append(m.declGenerators, constructor)
+
+	prefix := `With` + strings.TrimSuffix(m.name, `Mock`)
+	if m.delegate != nil {
+		m.declGenerators = append(m.declGenerators, newOptionFunc(prefix+`Delegate`, optionType,
+			FieldList{NewField("d", namedType(m.delegate, m.interFace))}, func(recv string) string { return recv + `.Delegate = d` }))
+	}
+	for _, method := range m.methods {
+		m.declGenerators = append(m.declGenerators, newOptionFunc(prefix+method.name, optionType,
+			FieldList{NewField("f", method.sig)}, func(recv string) string { return recv + `.` + method.fieldName + ` = f` }))
+		if method.results.Len() == 0 {
+			continue
+		}
+
+		results := method.results.WithNames("r")
+		var names []string
+		for _, field := range results {
+			names = append(names, field.Name())
+		}
+		stub := TypeString(unnamedSignature(method.sig)) + ` {` + "\n" + `return ` + strings.Join(names, `, `) + "\n" + `}`
+		fieldName := method.fieldName
+		m.declGenerators = append(m.declGenerators, newOptionFunc(prefix+method.name+`Returns`, optionType,
+			results, func(recv string) string { return recv + `.` + fieldName + ` = ` + stub }))
+	}
+	return nil
+}
+
+// newOptionFunc returns a func which returns an option to run stmt with the mock,
+// stmt is called with the name of the mock which does not conflict with params.
+func newOptionFunc(name string, optionType types.Type, params FieldList, stmt func(recv string) string) *Func {
+	fn := NewFunc(name, params, FieldList{NewField("", optionType)}, nil, "", false)
+	fn.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		used := make(map[string]bool)
+		for _, param := range params {
+			used[param.Name()] = true
+		}
+		recv := uniqueName("m", used)
+		fmt.Fprintln(w, `return func(`+recv+` `+TypeString(optionType.Underlying().(*types.Signature).Params().At(0).Type())+`) {`)
+		fmt.Fprintln(w, stmt(recv))
+		fmt.Fprintln(w, `}`)
+		return nil
+	})
+	return fn
+}
@@ -0,0 +1,42 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// WithContext makes the default behavior of methods which take a context.Context
+// as the first parameter honor the cancellation, they return ctx.Err() as the error
+// result if the context is done, and block until it is done if XxxHang is set.
+func WithContext() Option {
+	return func(m *SimpleMock) {
+		m.context = true
+	}
+}
+
+// contextParam returns the name of the first param if it is a context.Context.
+func contextParam(params FieldList) (string, bool) {
+	if params.Len() == 0 || !IsContext(params.At(0).Type()) {
+		return "", false
+	}
+	return params.At(0).Name(), true
+}
+
+// writeContextBlock writes the default behavior which honors the cancellation of ctx.
+func writeContextBlock(w io.Writer, recvName, ctx string, method *mockMethod) {
+	fmt.Fprintln(w, `if `+recvName+`.`+method.name+`Hang {`)
+	fmt.Fprintln(w, `<-`+ctx+`.Done()`)
+	fmt.Fprintln(w, `}`)
+
+	if ErrorResultIndex(method.results) < 0 {
+		return
+	}
+	fmt.Fprintln(w, `if `+ctx+`.Err() != nil {`)
+	fmt.Fprintln(w, method.results.Format(FormatReturnError(ctx+`.Err()`)))
+	fmt.Fprintln(w, `}`)
+}
+
+func newHangField(method string) *Field {
+	return NewField(method+`Hang`, types.Typ[types.Bool])
+}
@@ -0,0 +1,212 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// Decorator generates a non-test wrapper of a Delegate which instruments calls,
+// such as logging, metrics and middleware.
+type Decorator struct {
+	name    string
+	imports []string
+
+	structGenerator *Struct
+	funcGenerators  []*Func
+}
+
+// decoratorBlockWriter writes the body of a decorated method, call is a statement which calls
+// the Delegate and assigns named results, and used are names which must not be declared.
+type decoratorBlockWriter func(fn *Func, w io.Writer, call string, used map[string]bool)
+
+func newDecorator(name string, iface *types.TypeName, interFace *types.Interface, fields []*Field, blockWriter decoratorBlockWriter) (*Decorator, error) {
+	structGenerator := NewStruct(name, FieldList{})
+	for _, field := range append([]*Field{NewField("Delegate", namedType(iface, interFace))}, fields...) {
+		if err := structGenerator.AddField(field); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+	}
+
+	var funcGenerators []*Func
+	for i := 0; i < interFace.NumMethods(); i++ {
+		method := interFace.Method(i)
+		sig := method.Type().(*types.Signature)
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		params = params.WithNames("arg")
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+		results = results.WithNames("r")
+
+		used := make(map[string]bool)
+		for _, fl := range []FieldList{params, results} {
+			for _, field := range fl {
+				used[field.Name()] = true
+			}
+		}
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, uniqueName("m", used), sig.Variadic())
+		funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			recvName := fn.RecvName()
+			args := fn.Params().Format(FormatInputParams)
+			if fn.Variadic() {
+				args = fn.Params().Format(FormatInputParamsWithVariadic)
+			}
+			used := map[string]bool{recvName: true}
+			var names []string
+			for _, fl := range []FieldList{fn.Params(), fn.Results()} {
+				for _, field := range fl {
+					used[field.Name()] = true
+				}
+			}
+			for _, field := range fn.Results() {
+				names = append(names, field.Name())
+			}
+
+			call := recvName + `.Delegate.` + fn.Name() + args
+			if len(names) > 0 {
+				call = strings.Join(names, `, `) + ` = ` + call
+			}
+			blockWriter(fn, w, call, used)
+			if len(names) > 0 {
+				fmt.Fprintln(w, `return `+strings.Join(names, `, `))
+			}
+			return nil
+		})
+		funcGenerators = append(funcGenerators, funcGenerator)
+	}
+
+	return &Decorator{
+		name:            name,
+		structGenerator: structGenerator,
+		funcGenerators:  funcGenerators,
+	}, nil
+}
+
+// NewLoggingDecorator returns a decorator which logs arguments, results and the duration
+// of every call by a *slog.Logger, or slog.Default() if the Logger field is nil.
+// Calls which return a non-nil error are logged at the error level.
+func NewLoggingDecorator(name string, iface *types.TypeName, interFace *types.Interface) (*Decorator, error) {
+	logger := types.NewPointer(NewNamedType("log/slog", "Logger", types.NewStruct(nil, nil)))
+	d, err := newDecorator(name, iface, interFace, []*Field{NewField("Logger", logger)}, func(fn *Func, w io.Writer, call string, used map[string]bool) {
+		loggerVar := uniqueName("logger", used)
+		used[loggerVar] = true
+		startVar := uniqueName("start", used)
+		used[startVar] = true
+
+		ctx := `context.Background()`
+		if name, ok := contextParam(fn.Params()); ok {
+			ctx = name
+		}
+		var attrs []string
+		for _, fl := range []FieldList{fn.Params(), fn.Results()} {
+			for _, field := range fl {
+				if field.Name() == ctx {
+					continue
+				}
+				attrs = append(attrs, `slog.Any("`+field.Name()+`", `+field.Name()+`)`)
+			}
+		}
+		attrs = append(attrs, `slog.Duration("elapsed", time.Since(`+startVar+`))`)
+
+		fmt.Fprintln(w, loggerVar+` := `+fn.RecvName()+`.Logger`)
+		fmt.Fprintln(w, `if `+loggerVar+` == nil {`)
+		fmt.Fprintln(w, loggerVar+` = slog.Default()`)
+		fmt.Fprintln(w, `}`)
+		fmt.Fprintln(w, startVar+` := time.Now()`)
+		fmt.Fprintln(w, call)
+		level := `slog.LevelInfo`
+		if i := ErrorResultIndex(fn.Results()); i >= 0 {
+			level = uniqueName("level", used)
+			fmt.Fprintln(w, level+` := slog.LevelInfo`)
+			fmt.Fprintln(w, `if `+fn.Results().At(i).Name()+` != nil {`)
+			fmt.Fprintln(w, level+` = slog.LevelError`)
+			fmt.Fprintln(w, `}`)
+		}
+		fmt.Fprintln(w, loggerVar+`.LogAttrs(`+ctx+`, `+level+`, "`+iface.Name()+`.`+fn.Name()+`", `+strings.Join(attrs, `, `)+`)`)
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.imports = []string{"log/slog"}
+	return d, nil
+}
+
+// NewMetricsDecorator returns a decorator which calls the Observe hook with the method name,
+// the duration and the error result, or nil if the method has no error result, of every call.
+func NewMetricsDecorator(name string, iface *types.TypeName, interFace *types.Interface) (*Decorator, error) {
+	observe := types.NewSignatureType(nil, nil, nil, types.NewTuple(
+		types.NewParam(0, nil, "method", types.Typ[types.String]),
+		types.NewParam(0, nil, "elapsed", NewNamedType("time", "Duration", types.Typ[types.Int64])),
+		types.NewParam(0, nil, "err", types.Universe.Lookup("error").Type()),
+	), nil, false)
+	return newDecorator(name, iface, interFace, []*Field{NewField("Observe", observe)}, func(fn *Func, w io.Writer, call string, used map[string]bool) {
+		startVar := uniqueName("start", used)
+		errExpr := `nil`
+		if i := ErrorResultIndex(fn.Results()); i >= 0 {
+			errExpr = fn.Results().At(i).Name()
+		}
+
+		fmt.Fprintln(w, startVar+` := time.Now()`)
+		fmt.Fprintln(w, call)
+		fmt.Fprintln(w, `if `+fn.RecvName()+`.Observe != nil {`)
+		fmt.Fprintln(w, fn.RecvName()+`.Observe("`+fn.Name()+`", time.Since(`+startVar+`), `+errExpr+`)`)
+		fmt.Fprintln(w, `}`)
+	})
+}
+
+// NewMiddlewareDecorator returns a decorator which calls the Before hook with the method name
+// and arguments before every call, and the After hook with the method name and results after it.
+func NewMiddlewareDecorator(name string, iface *types.TypeName, interFace *types.Interface) (*Decorator, error) {
+	hook := func(values string) *types.Signature {
+		return types.NewSignatureType(nil, nil, nil, types.NewTuple(
+			types.NewParam(0, nil, "method", types.Typ[types.String]),
+			types.NewParam(0, nil, values, types.NewSlice(types.NewInterfaceType(nil, nil))),
+		), nil, false)
+	}
+	fields := []*Field{NewField("Before", hook("args")), NewField("After", hook("results"))}
+	return newDecorator(name, iface, interFace, fields, func(fn *Func, w io.Writer, call string, used map[string]bool) {
+		values := func(fl FieldList) string {
+			var names []string
+			for _, field := range fl {
+				names = append(names, field.Name())
+			}
+			return `[]interface{}{` + strings.Join(names, `, `) + `}`
+		}
+
+		recvName := fn.RecvName()
+		fmt.Fprintln(w, `if `+recvName+`.Before != nil {`)
+		fmt.Fprintln(w, recvName+`.Before("`+fn.Name()+`", `+values(fn.Params())+`)`)
+		fmt.Fprintln(w, `}`)
+		fmt.Fprintln(w, call)
+		fmt.Fprintln(w, `if `+recvName+`.After != nil {`)
+		fmt.Fprintln(w, recvName+`.After("`+fn.Name()+`", `+values(fn.Results())+`)`)
+		fmt.Fprintln(w, `}`)
+	})
+}
+
+func (d *Decorator) Name() string {
+	return d.name
+}
+
+func (d *Decorator) Imports() []string {
+	return d.imports
+}
+
+func (d *Decorator) WriteTo(w io.Writer) error {
+	if err := d.structGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate struct: %w", err)
+	}
+	for _, fg := range d.funcGenerators {
+		fmt.Fprintln(w)
+		if err := fg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate func: %w", err)
+		}
+	}
+	return nil
+}
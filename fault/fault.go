@@ -0,0 +1,156 @@
+// Package fault injects errors and latency into calls,
+// which is used by wrappers generated with -style fault.
+package fault
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// AnyMethod applies a rule to all methods.
+const AnyMethod = "*"
+
+// Distribution returns a latency drawn by r.
+type Distribution func(r *rand.Rand) time.Duration
+
+// Fixed returns a distribution of the constant latency d.
+func Fixed(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Uniform returns a uniform distribution between min and max.
+func Uniform(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// Exponential returns an exponential distribution whose mean is mean.
+func Exponential(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// Normal returns a normal distribution which is truncated at zero.
+func Normal(mean, stddev time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		d := time.Duration(r.NormFloat64()*float64(stddev)) + mean
+		if d < 0 {
+			return 0
+		}
+		return d
+	}
+}
+
+type rule struct {
+	method  string
+	call    int     // fails the nth call if > 0
+	prob    float64 // fails with the probability if call == 0
+	err     error
+	latency Distribution
+}
+
+func (r *rule) match(method string) bool {
+	return r.method == AnyMethod || r.method == method
+}
+
+// Injector injects faults into calls by the configured rules,
+// the nil Injector injects nothing.
+type Injector struct {
+	mu    sync.Mutex
+	rand  *rand.Rand
+	rules []*rule
+	calls map[string]int
+}
+
+// New returns an Injector whose random faults are drawn by a RNG of seed,
+// so that the faults are reproducible.
+func New(seed int64) *Injector {
+	return &Injector{
+		rand:  rand.New(rand.NewSource(seed)),
+		calls: make(map[string]int),
+	}
+}
+
+func (inj *Injector) add(r *rule) *Injector {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	inj.rules = append(inj.rules, r)
+	return inj
+}
+
+// ErrorOnCall fails the nth call of method with err, n starts from 1.
+func (inj *Injector) ErrorOnCall(method string, n int, err error) *Injector {
+	return inj.add(&rule{method: method, call: n, err: err})
+}
+
+// ErrorWithProbability fails calls of method with err by the probability p.
+func (inj *Injector) ErrorWithProbability(method string, p float64, err error) *Injector {
+	return inj.add(&rule{method: method, prob: p, err: err})
+}
+
+// Latency delays calls of method by a latency drawn from dist.
+func (inj *Injector) Latency(method string, dist Distribution) *Injector {
+	return inj.add(&rule{method: method, latency: dist})
+}
+
+// Calls returns the number of calls of method.
+func (inj *Injector) Calls(method string) int {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.calls[method]
+}
+
+// Inject counts a call of method, waits for the latencies, and returns the error
+// of the first failed rule. It returns ctx.Err() if ctx is done while waiting.
+func (inj *Injector) Inject(ctx context.Context, method string) error {
+	if inj == nil {
+		return nil
+	}
+
+	inj.mu.Lock()
+	inj.calls[method]++
+	n := inj.calls[method]
+	var (
+		delay time.Duration
+		err   error
+	)
+	for _, r := range inj.rules {
+		if !r.match(method) {
+			continue
+		}
+		switch {
+		case r.latency != nil:
+			delay += r.latency(inj.rand)
+		case r.call > 0:
+			if err == nil && r.call == n {
+				err = r.err
+			}
+		default:
+			// draw even if already failed to keep the sequence of the RNG stable
+			if inj.rand.Float64() < r.prob && err == nil {
+				err = r.err
+			}
+		}
+	}
+	inj.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
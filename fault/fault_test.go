@@ -0,0 +1,64 @@
+package fault_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/theoden9014/simplemock/fault"
+)
+
+func TestInjector_ErrorOnCall(t *testing.T) {
+	errFault := errors.New("fault")
+	inj := fault.New(1).ErrorOnCall("Get", 2, errFault)
+	for i, want := range []error{nil, errFault, nil} {
+		if err := inj.Inject(context.Background(), "Get"); err != want {
+			t.Errorf("call %d: Inject() = %v, want %v", i+1, err, want)
+		}
+	}
+	if err := inj.Inject(context.Background(), "Put"); err != nil {
+		t.Errorf("Inject() of other method = %v, want nil", err)
+	}
+}
+
+func TestInjector_ErrorWithProbability(t *testing.T) {
+	errFault := errors.New("fault")
+	run := func() []bool {
+		inj := fault.New(42).ErrorWithProbability(fault.AnyMethod, 0.5, errFault)
+		var failed []bool
+		for i := 0; i < 100; i++ {
+			failed = append(failed, inj.Inject(context.Background(), "Get") != nil)
+		}
+		return failed
+	}
+	first, second := run(), run()
+	var n int
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("call %d is not reproducible by the same seed", i+1)
+		}
+		if first[i] {
+			n++
+		}
+	}
+	if n == 0 || n == len(first) {
+		t.Errorf("%d of %d calls failed", n, len(first))
+	}
+}
+
+func TestInjector_Latency(t *testing.T) {
+	inj := fault.New(1).Latency("Get", fault.Fixed(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := inj.Inject(ctx, "Get"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Inject() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestInjector_Nil(t *testing.T) {
+	var inj *fault.Injector
+	if err := inj.Inject(context.Background(), "Get"); err != nil {
+		t.Errorf("Inject() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,120 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+const faultPkgPath = "github.com/theoden9014/simplemock/fault"
+
+// FaultMock generates a wrapper of a Delegate which injects errors and latency
+// into calls by a fault.Injector.
+type FaultMock struct {
+	name string
+
+	structGenerator *Struct
+	funcGenerators  []*Func
+}
+
+func NewFaultMock(name string, iface *types.TypeName, interFace *types.Interface) (*FaultMock, error) {
+	injector := types.NewPointer(NewNamedType(faultPkgPath, "Injector", types.NewStruct(nil, nil)))
+	structGenerator := NewStruct(name, FieldList{})
+	for _, field := range []*Field{
+		NewField("Delegate", namedType(iface, interFace)),
+		NewField("Injector", injector),
+	} {
+		if err := structGenerator.AddField(field); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+	}
+
+	var funcGenerators []*Func
+	for i := 0; i < interFace.NumMethods(); i++ {
+		method := interFace.Method(i)
+		sig := method.Type().(*types.Signature)
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		params = params.WithNames("arg")
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+
+		used := make(map[string]bool)
+		for _, fl := range []FieldList{params, results} {
+			for _, field := range fl {
+				used[field.Name()] = true
+			}
+		}
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, uniqueName("m", used), sig.Variadic())
+		funcGenerator.SetBlockWriter(writeFaultBlock)
+		funcGenerators = append(funcGenerators, funcGenerator)
+	}
+
+	m := &FaultMock{
+		name:            name,
+		structGenerator: structGenerator,
+		funcGenerators:  funcGenerators,
+	}
+	return m, nil
+}
+
+func writeFaultBlock(fn *Func, w io.Writer) error {
+	recvName := fn.RecvName()
+	params := fn.Params()
+	results := fn.Results()
+	args := params.Format(FormatInputParams)
+	if fn.Variadic() {
+		args = params.Format(FormatInputParamsWithVariadic)
+	}
+
+	ctx := `context.Background()`
+	if name, ok := contextParam(params); ok {
+		ctx = name
+	}
+	inject := recvName + `.Injector.Inject(` + ctx + `, "` + fn.Name() + `")`
+	if ErrorResultIndex(results) < 0 {
+		// only latency is injected into methods which can not return an error
+		fmt.Fprintln(w, inject)
+	} else {
+		used := map[string]bool{recvName: true}
+		for _, field := range params {
+			used[field.Name()] = true
+		}
+		errVar := uniqueName("err", used)
+		fmt.Fprintln(w, `if `+errVar+` := `+inject+`; `+errVar+` != nil {`)
+		fmt.Fprintln(w, results.Format(FormatReturnError(errVar)))
+		fmt.Fprintln(w, `}`)
+	}
+
+	if results.Len() == 0 {
+		fmt.Fprintln(w, recvName+`.Delegate.`+fn.Name()+args)
+	} else {
+		fmt.Fprintln(w, `return `+recvName+`.Delegate.`+fn.Name()+args)
+	}
+	return nil
+}
+
+func (m *FaultMock) Name() string {
+	return m.name
+}
+
+func (m *FaultMock) Imports() []string {
+	return []string{faultPkgPath}
+}
+
+func (m *FaultMock) WriteTo(w io.Writer) error {
+	if err := m.structGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate struct: %w", err)
+	}
+	for _, fg := range m.funcGenerators {
+		fmt.Fprintln(w)
+		if err := fg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate func: %w", err)
+		}
+	}
+	return nil
+}
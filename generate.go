@@ -0,0 +1,507 @@
+package simplemock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Options configures Generate.
+type Options struct {
+	// Patterns are package patterns or Go files to load, as go/packages.
+	Patterns []string
+	// Types are names of types to generate from, all exported interface and func types if empty.
+	Types []string
+	// Extract are names of concrete types to extract an interface of the exported method set from,
+	// instead of interfaces. PackageName must be another package than the loaded one.
+	Extract []string
+	// Styles are generated styles, "mock" if empty.
+	Styles []string
+	// Features are optional features of mocks.
+	Features []string
+	// Plugins are names of registered plugins of mocks.
+	Plugins []string
+	// PackageName is the package name of the generated file, the loaded package name if empty.
+	PackageName string
+	// Output is the path of the generated file, which is only set to GeneratedFile.Path.
+	// A relative path is in the directory of each package.
+	Output string
+	// Template is the path of a text/template file which is rendered with TemplateData
+	// instead of Styles.
+	Template string
+	// Emit is the kind of the output, "code" or "model" which emits models of types as JSON.
+	// It is "code" if empty.
+	Emit string
+	// Dir is the directory in which packages are loaded, the current directory if empty.
+	Dir string
+	// Tags are build tags of loaded files.
+	Tags []string
+	// GOOS and GOARCH are the target platform of loaded files, the host platform if empty.
+	GOOS, GOARCH string
+	// Tests generates from types declared in test files of the package, into a file of the test
+	// variant of the package and a file of the external test package, whose Output must be a
+	// _test.go file, and the file of the external test package is suffixed with _external_test.go.
+	Tests bool
+	// BuildConstraint emits a //go:build line of Tags, GOOS and GOARCH in the generated code.
+	BuildConstraint bool
+	// Parallelism is the number of packages generated in parallel, GOMAXPROCS if it is not positive.
+	Parallelism int
+	// Cache skips generation of files whose types, generator and options are not changed
+	// since they are stored in it, if it is not nil. Templates and models are always generated.
+	Cache *Cache
+	// Syntax generates from the syntax and import declarations of the package alone without
+	// type-checking it. Types which refer to types with errors are always generated from syntax.
+	Syntax bool
+}
+
+// GeneratedFile is a formatted source file generated by Generate.
+type GeneratedFile struct {
+	Path    string
+	Package string
+	Source  []byte
+	// Diagnostics are problems which did not stop the generation.
+	Diagnostics []Diagnostic
+	// CacheKey is the key of the file, which is stored into Options.Cache after writing the file.
+	CacheKey string
+	// Cached reports whether the generation is skipped since the file at Path is up to date,
+	// then Source is nil.
+	Cached bool
+}
+
+// Diagnostic is a problem found while generating.
+type Diagnostic struct {
+	// Pos is the position of the problem, which is invalid if it is unknown.
+	Pos     token.Position
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Pos.IsValid() {
+		return d.Pos.String() + ": " + d.Message
+	}
+	return d.Message
+}
+
+// Generate loads packages of opts.Patterns and generates code of opts.Styles from their types,
+// or renders opts.Template if it is set, or emits their models if opts.Emit is "model".
+// A file is generated for each package in parallel, and its path is opts.Output in the directory
+// of the package, which is required if multiple packages are loaded. Files of the generated packages
+// are returned with errors of the other packages.
+func Generate(ctx context.Context, opts Options) ([]GeneratedFile, error) {
+	switch opts.Emit {
+	case "", "code":
+	case "model":
+		return generateModel(ctx, opts)
+	default:
+		return nil, fmt.Errorf("unknown emit %q, available emits are code, model", opts.Emit)
+	}
+	if len(opts.Template) != 0 {
+		return generateTemplate(ctx, opts)
+	}
+	styleNames := opts.Styles
+	if len(styleNames) == 0 {
+		styleNames = []string{"mock"}
+	}
+	styleFuncs, err := parseStyles(strings.Join(styleNames, ","))
+	if err != nil {
+		return nil, err
+	}
+	features, err := parseFeatures(strings.Join(opts.Features, ","))
+	if err != nil {
+		return nil, err
+	}
+	pluginOpts, err := parsePlugins(strings.Join(opts.Plugins, ","))
+	if err != nil {
+		return nil, err
+	}
+	if opts.Tests {
+		if len(opts.PackageName) != 0 {
+			return nil, errors.New("test files are generated into the packages of the types, package name can not be specified")
+		}
+		// the test variant and the external test package can not be output to stdout together
+		if len(opts.Output) == 0 {
+			return nil, errors.New("test files are generated into the packages of the types, output must be specified")
+		}
+		if !strings.HasSuffix(opts.Output, "_test.go") {
+			return nil, fmt.Errorf("output %s of test files must be a _test.go file", opts.Output)
+		}
+	}
+	g := &codeGenerator{
+		opts:         opts,
+		styleFuncs:   styleFuncs,
+		features:     append(features, pluginOpts...),
+		typeNames:    nameSet(opts.Types),
+		extractNames: nameSet(opts.Extract),
+	}
+
+	return generatePackages(ctx, opts, g.generate)
+}
+
+// generatePackages loads packages of opts.Patterns, and generates a file of each package by generate in parallel.
+// Files of the generated packages are returned in the order of packages with errors of the other packages.
+func generatePackages(ctx context.Context, opts Options, generate func(ctx context.Context, pkg *loadedPackage, path string) (GeneratedFile, error)) ([]GeneratedFile, error) {
+	pkgs, err := load(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) > 1 && len(opts.Output) == 0 {
+		return nil, fmt.Errorf("output must be specified, since %d packages are generated", len(pkgs))
+	}
+	dirs := make(map[string]bool)
+	for _, pkg := range pkgs {
+		dirs[pkg.dir()] = true
+	}
+	if len(dirs) > 1 && filepath.IsAbs(opts.Output) {
+		return nil, fmt.Errorf("output %s must be a path relative to directories of packages, since multiple packages are generated", opts.Output)
+	}
+
+	paths := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		paths[i] = outputPath(opts, pkg)
+	}
+
+	// packages are generated by workers, and files are in the order of packages
+	results := make([]GeneratedFile, len(pkgs))
+	errs := make([]error, len(pkgs))
+	indexes := make(chan int)
+	workers := opts.Parallelism
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i], errs[i] = generate(ctx, pkgs[i], paths[i])
+			}
+		}()
+	}
+	for i := range pkgs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var files []GeneratedFile
+	var errList errorList
+	for i, pkg := range pkgs {
+		if err := errs[i]; err != nil {
+			if len(pkgs) > 1 {
+				err = fmt.Errorf("%s: %w", pkg.ID, err)
+			}
+			errList = append(errList, err)
+			continue
+		}
+		files = append(files, results[i])
+	}
+	switch len(errList) {
+	case 0:
+		return files, nil
+	case 1:
+		return files, errList[0]
+	}
+	return files, errList
+}
+
+// outputPath returns the path of the file of the package, which is opts.Output in the directory
+// of the package if it is relative, or empty to output to stdout.
+// The file of the external test package is suffixed with _external_test.go instead of _test.go,
+// or _external before the extension of other files.
+func outputPath(opts Options, pkg *loadedPackage) string {
+	path := opts.Output
+	if len(path) == 0 {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(pkg.dir(), path)
+	}
+	if opts.Tests && strings.HasSuffix(pkg.Name, "_test") {
+		if strings.HasSuffix(path, "_test.go") {
+			path = strings.TrimSuffix(path, "_test.go") + "_external_test.go"
+		} else {
+			ext := filepath.Ext(path)
+			path = strings.TrimSuffix(path, ext) + "_external" + ext
+		}
+	}
+	return path
+}
+
+// errorList is errors of generation of packages.
+type errorList []error
+
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l errorList) Unwrap() []error {
+	return l
+}
+
+// codeGenerator generates code of styles from types of loaded packages.
+type codeGenerator struct {
+	opts         Options
+	styleFuncs   []styleFunc
+	features     []Option
+	typeNames    map[string]bool
+	extractNames map[string]bool
+}
+
+// generate returns a file of path of code generated from types of the package.
+func (g *codeGenerator) generate(ctx context.Context, pkg *loadedPackage, path string) (GeneratedFile, error) {
+	opts := g.opts
+	if pkg.Err != nil {
+		return GeneratedFile{}, pkg.Err
+	}
+	gofile := NewGoFile()
+	file := GeneratedFile{Path: path, Package: opts.PackageName}
+	var key cacheKey
+	if len(file.Package) == 0 {
+		file.Package = pkg.Name
+	}
+
+	// local types are unqualified in the same package
+	var localPkg *types.Package
+	if file.Package != pkg.Name {
+		localPkg = types.NewPackage(pkg.PkgPath, pkg.Name)
+	}
+	// typePkg qualifies the generated type, which is declared in the generated code if it is extracted
+	typePkg := localPkg
+	if len(g.extractNames) > 0 {
+		typePkg = nil
+	}
+	// a package of files given as patterns can not be imported
+	if typePkg != nil && pkg.PkgPath != "command-line-arguments" {
+		gofile.Import.Add(pkg.PkgPath)
+	}
+
+	generate := func(name string, typ types.Type, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		key.add(name, typ)
+		obj := types.NewTypeName(token.NoPos, typePkg, name, nil)
+		for _, styleFunc := range g.styleFuncs {
+			mock, err := styleFunc(obj, typ, g.features...)
+			if err != nil {
+				return fmt.Errorf("SimpleMock: %w", err)
+			}
+			if mock == nil {
+				continue
+			}
+			if im, ok := mock.(importer); ok {
+				for _, pkg := range im.Imports() {
+					gofile.Import.Add(pkg)
+				}
+			}
+			if err := mock.WriteTo(gofile); err != nil {
+				return err
+			}
+			fmt.Fprintln(gofile)
+		}
+		return nil
+	}
+
+	var info typeInfo
+	if pkg.TypesInfo != nil {
+		info = pkg.TypesInfo
+	}
+	resolver := newSyntaxResolver(pkg.Fset, localPkg, pkg.Syntax, info)
+	// generateSyntax generates the type resolved from syntax. d is reported with the reason
+	// if it can not be resolved, or with the message generated if it is not empty.
+	generateSyntax := func(name string, d Diagnostic, generated string) error {
+		typ, typeFile, err := resolver.typeOf(name)
+		if err != nil {
+			d.Message += ": " + err.Error()
+			file.Diagnostics = append(file.Diagnostics, d)
+			return nil
+		}
+		if generated != "" {
+			d.Message = generated
+			file.Diagnostics = append(file.Diagnostics, d)
+		}
+		addImports(gofile.Import, typeFile)
+		return generate(name, typ, nil)
+	}
+	// selected reports whether the type is selected by opts.Types and has no errors.
+	selected := func(name string, typ types.Type) bool {
+		if len(g.typeNames) > 0 && !g.typeNames[name] {
+			return false
+		}
+		if d := checkType(pkg.Package, name, typ); d != nil {
+			file.Diagnostics = append(file.Diagnostics, *d)
+			return false
+		}
+		return true
+	}
+
+	var err error
+	extracted := make(map[string]bool)
+	for _, node := range pkg.Files {
+		switch {
+		case opts.Syntax:
+			if len(g.extractNames) > 0 {
+				return file, errors.New("interfaces can not be extracted from syntax")
+			}
+			for _, name := range resolver.names(node) {
+				if len(g.typeNames) > 0 && !g.typeNames[name] {
+					continue
+				}
+				d := Diagnostic{Pos: resolver.position(name), Message: fmt.Sprintf("%s is not generated", name)}
+				if err = generateSyntax(name, d, ""); err != nil {
+					break
+				}
+			}
+		case len(g.extractNames) == 0:
+			err = walk(node, pkg.TypesInfo, func(name string, typ types.Type, err error) error {
+				if err != nil || len(g.typeNames) > 0 && !g.typeNames[name] {
+					return err
+				}
+				if d := checkType(pkg.Package, name, typ); d != nil {
+					return generateSyntax(name, *d, fmt.Sprintf("%s is generated from syntax since it refers to types which have errors", name))
+				}
+				if localPkg == nil {
+					typ = localizeType(typ, pkg.Types)
+				}
+				addTypeImports(gofile.Import, typ)
+				return generate(name, typ, nil)
+			})
+		case file.Package == pkg.Name:
+			return file, fmt.Errorf("extracted interfaces must be generated into another package than %s, specify another package name", pkg.Name)
+		default:
+			err = walkExtract(node, pkg.TypesInfo, g.extractNames, func(name string, typ types.Type, err error) error {
+				extracted[name] = true
+				if err != nil || !selected(name, typ) {
+					return err
+				}
+				if err := NewInterface(name, typ.(*types.Interface)).WriteTo(gofile); err != nil {
+					return fmt.Errorf("Interface: %w", err)
+				}
+				fmt.Fprintln(gofile)
+				addTypeImports(gofile.Import, typ)
+				return generate(name, typ, nil)
+			})
+		}
+		if err != nil {
+			return file, err
+		}
+	}
+	if len(g.extractNames) > 0 {
+		if err := extractNotFound(g.extractNames, extracted); err != nil {
+			return file, err
+		}
+	}
+	file.Diagnostics = append(pkg.Diagnostics, file.Diagnostics...)
+	if opts.Cache != nil && len(file.Path) != 0 {
+		file.CacheKey = key.sum(opts, file)
+		if opts.Cache.Lookup(file.Path, file.CacheKey) {
+			file.Cached = true
+			return file, nil
+		}
+	}
+
+	gofile.Package = file.Package
+	if opts.BuildConstraint {
+		gofile.BuildConstraint = buildConstraint(opts)
+	}
+	if err := gofile.Generate(); err != nil {
+		file.Diagnostics = append(file.Diagnostics, Diagnostic{Message: fmt.Sprintf("generate source code: %v", err)})
+	}
+	if err := gofile.Format(); err != nil {
+		file.Diagnostics = append(file.Diagnostics, Diagnostic{Message: fmt.Sprintf("format source code: %v", err)})
+	}
+	for _, name := range undefinedPackages(gofile.Bytes()) {
+		file.Diagnostics = append(file.Diagnostics, Diagnostic{Message: fmt.Sprintf("generated code refers to package %s which is not imported", name)})
+	}
+	file.Source = gofile.Bytes()
+	return file, nil
+}
+
+// undefinedPackages returns names of packages which qualify identifiers in src, but are not imported,
+// such as the package of files given as patterns.
+func undefinedPackages(src []byte) []string {
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil
+	}
+	imported := make(map[string]bool)
+	for _, spec := range f.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			imported[spec.Name.Name] = true
+		} else {
+			imported[importName(path)] = true
+		}
+	}
+	var names []string
+	seen := make(map[string]bool)
+	ast.Inspect(f, func(node ast.Node) bool {
+		sel, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && !imported[x.Name] && !seen[x.Name] {
+			seen[x.Name] = true
+			names = append(names, x.Name)
+		}
+		return true
+	})
+	return names
+}
+
+// addTypeImports adds imports of packages referred by typ, which goimports may not find
+// such as packages of the same module.
+func addTypeImports(im *Import, typ types.Type) {
+	for _, path := range importPaths(typ) {
+		// a package of files given as patterns can not be imported
+		if path != "command-line-arguments" {
+			im.Add(path)
+		}
+	}
+}
+
+// buildConstraint returns an expression of a //go:build line which is satisfied by
+// the build tags and the platform of opts, which is empty if they are not set.
+func buildConstraint(opts Options) string {
+	terms := trimNames(append(append([]string(nil), opts.Tags...), opts.GOOS, opts.GOARCH))
+	return strings.Join(terms, " && ")
+}
+
+// trimNames returns non-empty names without spaces.
+func trimNames(names []string) []string {
+	var trimmed []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			trimmed = append(trimmed, name)
+		}
+	}
+	return trimmed
+}
+
+// nameSet returns a set of non-empty names.
+func nameSet(names []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,827 @@
+package simplemock
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerate(t *testing.T) {
+	src := `package util
+
+// Getter gets values.
+type Getter interface {
+	// Get returns the value of key.
+	Get(key string) string
+}
+
+type Closer interface {
+	Close() error
+}
+`
+	tests := []struct {
+		name string
+		src  string
+		tmpl string
+		// files are other files of the module, which is loaded by the directory if set
+		files map[string]string
+		opts  Options
+		want  string
+		// wantExternal is the file of the external test package
+		wantExternal string
+		wantDiags    []string
+		wantErr      bool
+	}{
+		{
+			name: "types",
+			opts: Options{Types: []string{"Closer"}, Styles: []string{"nop"}},
+			want: `package util
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+`,
+		},
+		{
+			name: "package name",
+			opts: Options{Types: []string{"Getter"}, Styles: []string{"adapter"}, PackageName: "utiltest"},
+			want: `package utiltest
+
+type GetterFunc func(string) string
+
+func (f GetterFunc) Get(key string) string {
+	return f(key)
+}
+`,
+		},
+		{
+			name: "template",
+			src: `package util
+
+import "io"
+
+// List is a list.
+type List[T any] interface {
+	// Append appends values.
+	Append(...T) int
+	// WriteTo writes the list.
+	WriteTo(w io.Writer) (n int64, err error)
+}
+`,
+			tmpl: `{{range .Types}}{{.Name}} {{.Kind}} {{.Package}} {{.PackagePath}} {{.Imports}} {{.Doc}}
+{{range .TypeParams}}[{{.Name}} {{.Type}}]
+{{end}}{{range .Methods}}{{.Name}}({{paramList .Params}}) {{range .Results}}{{typeString .}}={{zeroValue .}} {{end}}// {{.Doc}}
+{{end}}{{end}}`,
+			want: `List interface util command-line-arguments [io] List is a list.
+[T any]
+Append(arg0 ...T) int=0 // Append appends values.
+WriteTo(w io.Writer) int64=0 error=nil // WriteTo writes the list.
+`,
+		},
+		{
+			name: "template go source",
+			tmpl: `package {{.PackageName}}
+{{range .Types}}
+// Nop{{.Name}} has {{len .Methods}} methods.
+var Nop{{.Name}} = struct{}{}
+{{end}}`,
+			opts: Options{Types: []string{"Getter"}, PackageName: "utiltest"},
+			want: `package utiltest
+
+// NopGetter has 1 methods.
+var NopGetter = struct{}{}
+`,
+		},
+		{
+			name: "emit model",
+			opts: Options{Types: []string{"Getter"}, Emit: "model"},
+			want: `[
+  {
+    "name": "Getter",
+    "package": "util",
+    "packagePath": "command-line-arguments",
+    "kind": "interface",
+    "doc": "Getter gets values.",
+    "pos": "DIR/x.go:4:6",
+    "methods": [
+      {
+        "name": "Get",
+        "doc": "Get returns the value of key.",
+        "pos": "DIR/x.go:6:2",
+        "params": [
+          {
+            "name": "key",
+            "type": "string",
+            "qualifiedType": "string"
+          }
+        ],
+        "results": [
+          {
+            "type": "string",
+            "qualifiedType": "string"
+          }
+        ]
+      }
+    ]
+  }
+]
+`,
+		},
+		{
+			name: "type errors",
+			src: `package util
+
+type Getter interface {
+	Get(key Key) string
+}
+
+type Closer interface {
+	Close() error
+}
+
+var _ = undefined
+`,
+			opts: Options{Styles: []string{"nop"}},
+			want: `package util
+
+type NopGetter struct {
+}
+
+func (NopGetter) Get(key Key) string {
+	return ""
+}
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+`,
+			wantDiags: []string{
+				"DIR/x.go:4:10: undefined: Key",
+				"DIR/x.go:11:9: undefined: undefined",
+				"DIR/x.go:3:6: Getter is generated from syntax since it refers to types which have errors",
+			},
+		},
+		{
+			name: "syntax fallback",
+			src: `package util
+
+import (
+	str "strings"
+	"time"
+)
+
+type Key string
+
+type Closer interface {
+	Close() error
+}
+
+type Getter interface {
+	Closer
+	Get(b *str.Builder, timeout time.Duration, key Key) (Value, error)
+	Keys(keys ...Key) map[Key][]byte
+}
+`,
+			opts: Options{Styles: []string{"nop"}},
+			want: `package util
+
+import (
+	str "strings"
+	"time"
+)
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+
+type NopGetter struct {
+}
+
+func (NopGetter) Close() error {
+	return nil
+}
+
+func (NopGetter) Get(b *str.Builder, timeout time.Duration, key Key) (Value, error) {
+	return *new(Value), nil
+}
+
+func (NopGetter) Keys(keys ...Key) map[Key][]byte {
+	return nil
+}
+`,
+			wantDiags: []string{
+				"DIR/x.go:16:55: undefined: Value",
+				"DIR/x.go:14:6: Getter is generated from syntax since it refers to types which have errors",
+			},
+		},
+		{
+			name: "syntax",
+			src: `package util
+
+import "io"
+
+type Key string
+
+type Getter interface {
+	Get(key Key) string
+}
+
+type ReadCloser interface {
+	io.Closer
+	Read() error
+}
+`,
+			opts: Options{Styles: []string{"nop"}, Syntax: true},
+			want: `package util
+
+type NopGetter struct {
+}
+
+func (NopGetter) Get(key Key) string {
+	return ""
+}
+`,
+			wantDiags: []string{
+				"DIR/x.go:11:6: ReadCloser is not generated: embedded interface io.Closer can not be resolved from syntax",
+			},
+		},
+		{
+			name: "local types",
+			src: `package util
+
+type Key string
+
+type Getter interface {
+	Get(key Key) (Key, error)
+}
+`,
+			opts: Options{Styles: []string{"nop"}},
+			want: `package util
+
+type NopGetter struct {
+}
+
+func (NopGetter) Get(key Key) (Key, error) {
+	return "", nil
+}
+`,
+		},
+		{
+			name: "build tags and platform",
+			files: map[string]string{
+				"go.mod": "module example.com/util\n\ngo 1.18\n",
+				"y.go": `//go:build integration
+
+package util
+
+type Store interface {
+	Load() error
+}
+`,
+				"z_windows.go": `package util
+
+type Service interface {
+	Stop() error
+}
+`,
+			},
+			opts: Options{
+				Types:           []string{"Store", "Service"},
+				Styles:          []string{"nop"},
+				Tags:            []string{"integration"},
+				GOOS:            "windows",
+				BuildConstraint: true,
+			},
+			want: `//go:build integration && windows
+
+package util
+
+type NopStore struct {
+}
+
+func (NopStore) Load() error {
+	return nil
+}
+
+type NopService struct {
+}
+
+func (NopService) Stop() error {
+	return nil
+}
+`,
+		},
+		{
+			name: "without build tags",
+			files: map[string]string{
+				"go.mod": "module example.com/util\n\ngo 1.18\n",
+				"y.go": `//go:build integration
+
+package util
+
+type Store interface {
+	Load() error
+}
+`,
+			},
+			opts: Options{Types: []string{"Store", "Closer"}, Styles: []string{"nop"}},
+			want: `package util
+
+type NopCloser struct {
+}
+
+func (NopCloser) Close() error {
+	return nil
+}
+`,
+		},
+		{
+			name: "test files",
+			files: map[string]string{
+				"go.mod": "module example.com/util\n\ngo 1.18\n",
+				"export_test.go": `package util
+
+type Clock interface {
+	Now() int64
+}
+`,
+				"x_test.go": `package util_test
+
+import "example.com/util"
+
+type Cache interface {
+	Getter() util.Getter
+}
+`,
+			},
+			opts: Options{Styles: []string{"nop"}, Tests: true, Output: "mock_test.go"},
+			want: `package util
+
+type NopClock struct {
+}
+
+func (NopClock) Now() int64 {
+	return 0
+}
+`,
+			wantExternal: `package util_test
+
+import (
+	"example.com/util"
+)
+
+type NopCache struct {
+}
+
+func (NopCache) Getter() util.Getter {
+	return nil
+}
+`,
+		},
+		{
+			name:  "package name",
+			files: map[string]string{"go.mod": "module example.com/util\n\ngo 1.18\n"},
+			opts:  Options{Types: []string{"Closer"}, Styles: []string{"spy"}, PackageName: "utilmock"},
+			want: `package utilmock
+
+import (
+	"sync"
+
+	"example.com/util"
+)
+
+type CloserSpy struct {
+	Delegate   util.Closer
+	CloseFunc  func() error
+	mu         sync.Mutex
+	closeCalls []CloserSpyCloseCall
+}
+
+type CloserSpyCloseCall struct {
+	R0    error
+	Panic interface{}
+}
+
+func (m *CloserSpy) Close() (r0 error) {
+	call := CloserSpyCloseCall{}
+	defer func() {
+		call.Panic = recover()
+		call.R0 = r0
+		m.mu.Lock()
+		m.closeCalls = append(m.closeCalls, call)
+		m.mu.Unlock()
+		if call.Panic != nil {
+			panic(call.Panic)
+		}
+	}()
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	if m.Delegate != nil {
+		return m.Delegate.Close()
+	}
+	return nil
+}
+
+func (m *CloserSpy) CloseCalls() []CloserSpyCloseCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CloserSpyCloseCall(nil), m.closeCalls...)
+}
+`,
+		},
+		{
+			name: "package name of files",
+			opts: Options{Types: []string{"Closer"}, Styles: []string{"spy"}, PackageName: "utilmock"},
+			want: `package utilmock
+
+import "sync"
+
+type CloserSpy struct {
+	Delegate   util.Closer
+	CloseFunc  func() error
+	mu         sync.Mutex
+	closeCalls []CloserSpyCloseCall
+}
+
+type CloserSpyCloseCall struct {
+	R0    error
+	Panic interface{}
+}
+
+func (m *CloserSpy) Close() (r0 error) {
+	call := CloserSpyCloseCall{}
+	defer func() {
+		call.Panic = recover()
+		call.R0 = r0
+		m.mu.Lock()
+		m.closeCalls = append(m.closeCalls, call)
+		m.mu.Unlock()
+		if call.Panic != nil {
+			panic(call.Panic)
+		}
+	}()
+	if m.CloseFunc != nil {
+		return m.CloseFunc()
+	}
+	if m.Delegate != nil {
+		return m.Delegate.Close()
+	}
+	return nil
+}
+
+func (m *CloserSpy) CloseCalls() []CloserSpyCloseCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]CloserSpyCloseCall(nil), m.closeCalls...)
+}
+`,
+			wantDiags: []string{"generated code refers to package util which is not imported"},
+		},
+		{
+			name:    "unknown emit",
+			opts:    Options{Emit: "unknown"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown plugin",
+			opts:    Options{Plugins: []string{"unknown"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown style",
+			opts:    Options{Styles: []string{"unknown"}},
+			wantErr: true,
+		},
+		{
+			name:    "test files without output",
+			opts:    Options{Styles: []string{"nop"}, Tests: true},
+			wantErr: true,
+		},
+		{
+			name:    "test files into a non-test file",
+			opts:    Options{Styles: []string{"nop"}, Tests: true, Output: "mock.go"},
+			wantErr: true,
+		},
+		{
+			name:    "extract into the same package",
+			opts:    Options{Extract: []string{"Getter"}},
+			wantErr: true,
+		},
+		{
+			// Getter is an interface, which is not a concrete type to extract
+			name:    "extract not found",
+			opts:    Options{Extract: []string{"Getter", "Missing"}, PackageName: "utilmock"},
+			wantErr: true,
+		},
+		{
+			name:    "extract model not found",
+			opts:    Options{Extract: []string{"Missing"}, PackageName: "utilmock", Emit: "model"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "x.go")
+			if tt.src == "" {
+				tt.src = src
+			}
+			if err := os.WriteFile(path, []byte(tt.src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			tt.opts.Patterns = []string{path}
+			if tt.files != nil {
+				for name, content := range tt.files {
+					if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				tt.opts.Dir = dir
+				tt.opts.Patterns = []string{"."}
+			}
+			if tt.tmpl != "" {
+				tt.opts.Template = filepath.Join(dir, "x.tmpl")
+				if err := os.WriteFile(tt.opts.Template, []byte(tt.tmpl), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			files, err := Generate(context.Background(), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			wantFiles := 1
+			if tt.wantExternal != "" {
+				wantFiles = 2
+			}
+			if len(files) != wantFiles {
+				t.Fatalf("Generate() returned %d files, want %d", len(files), wantFiles)
+			}
+			if tt.wantExternal != "" {
+				if diff := cmp.Diff(tt.wantExternal, string(files[1].Source)); diff != "" {
+					t.Errorf("Generate() external test package mismatch (-want +got):\n%s", diff)
+				}
+			}
+			got := strings.ReplaceAll(string(files[0].Source), dir, "DIR")
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Generate() mismatch (-want +got):\n%s", diff)
+			}
+			var gotDiags []string
+			for _, d := range files[0].Diagnostics {
+				gotDiags = append(gotDiags, strings.ReplaceAll(d.String(), dir, "DIR"))
+			}
+			if diff := cmp.Diff(tt.wantDiags, gotDiags); diff != "" {
+				t.Errorf("Generate() diagnostics mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerate_Packages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.18\n",
+		"c/c.go": "package c\n\ntype Closer interface {\n\tClose() error\n}\n",
+		"a/a.go": "package a\n\ntype Getter interface {\n\tGet(key string) string\n}\n",
+		// a partial mock of an interface which has a method of the same name is an error
+		"b/b.go": "package b\n\ntype Get interface {\n\tGet() string\n}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generated, err := Generate(context.Background(), Options{
+		Patterns:    []string{"./..."},
+		Dir:         dir,
+		Styles:      []string{"partial"},
+		Output:      "mock.go",
+		Parallelism: 2,
+	})
+	if err == nil || !strings.HasPrefix(err.Error(), "example.com/m/b: ") {
+		t.Errorf("Generate() error = %v, want an error of example.com/m/b", err)
+	}
+	var got []string
+	for _, file := range generated {
+		path, err := filepath.Rel(dir, file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, file.Package+" "+filepath.ToSlash(path))
+	}
+	want := []string{"a a/mock.go", "c c/mock.go"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() files mismatch (-want +got):\n%s", diff)
+	}
+
+	// a relative output is in the directory of a single package too
+	generated, err = Generate(context.Background(), Options{
+		Patterns: []string{"./a"},
+		Dir:      dir,
+		Styles:   []string{"nop"},
+		Output:   "mock.go",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "a", "mock.go"); generated[0].Path != want {
+		t.Errorf("Generate() path = %s, want %s", generated[0].Path, want)
+	}
+
+	// models are emitted into a file of each package
+	generated, err = Generate(context.Background(), Options{
+		Patterns: []string{"./a", "./c"},
+		Dir:      dir,
+		Emit:     "model",
+		Output:   "models.json",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	for _, file := range generated {
+		path, err := filepath.Rel(dir, file.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var models []*Model
+		if err := json.Unmarshal(file.Source, &models); err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range models {
+			got = append(got, filepath.ToSlash(path)+" "+m.Name)
+		}
+	}
+	want = []string{"a/models.json Getter", "c/models.json Closer"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() models mismatch (-want +got):\n%s", diff)
+	}
+
+	// files of multiple packages can not be output to stdout
+	if _, err := Generate(context.Background(), Options{
+		Patterns: []string{"./a", "./c"},
+		Dir:      dir,
+		Styles:   []string{"nop"},
+	}); err == nil {
+		t.Error("Generate() without output error = nil, want an error")
+	}
+}
+
+func TestGenerate_Hooks(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command is not found")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.18\n",
+		"m.go":   "package m\n\ntype Flusher interface {\n\tFlush()\n}\n",
+		"m_test.go": `package m
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlusherMock_FlushCalled(t *testing.T) {
+	m := &FlusherMock{}
+	// calls before FlushCalled, and more than the buffer of it
+	for i := 0; i < 100; i++ {
+		m.Flush()
+	}
+	for i := 0; i < 100; i++ {
+		select {
+		case <-m.FlushCalled():
+		case <-time.After(time.Second):
+			t.Fatalf("FlushCalled() received %d values, want 100", i)
+		}
+	}
+	select {
+	case <-m.FlushCalled():
+		t.Error("FlushCalled() received a value without a call")
+	default:
+	}
+}
+
+func TestFlusherMock_Reset(t *testing.T) {
+	m := &FlusherMock{}
+	for i := 0; i < 3; i++ {
+		m.Flush()
+		<-m.FlushCalled()
+	}
+	// calls before Reset are not signaled again
+	m.Reset()
+	m.Flush()
+	<-m.FlushCalled()
+	select {
+	case <-m.FlushCalled():
+		t.Error("FlushCalled() received a value of a call before Reset")
+	default:
+	}
+}
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generated, err := Generate(context.Background(), Options{
+		Patterns: []string{"."},
+		Dir:      dir,
+		Styles:   []string{"mock"},
+		Features: []string{"hooks", "reset"},
+		Output:   filepath.Join(dir, "mock.go"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(generated[0].Path, generated[0].Source, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "test", ".")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("go test: %v\n%s", err, out)
+	}
+}
+
+func TestGenerate_Cache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "x.go")
+	out := filepath.Join(dir, "mock.go")
+	cachePath := filepath.Join(dir, "cache", "cache.json")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// generate generates the output through the cache file, and reports whether it is cached
+	generate := func() bool {
+		t.Helper()
+		cache, err := OpenCache(cachePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err := Generate(context.Background(), Options{Patterns: []string{src}, Styles: []string{"nop"}, Output: out, Cache: cache})
+		if err != nil {
+			t.Fatal(err)
+		}
+		file := files[0]
+		if file.Cached {
+			return true
+		}
+		writeFile(file.Path, string(file.Source))
+		if err := cache.Store(file.Path, file.CacheKey); err != nil {
+			t.Fatal(err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatal(err)
+		}
+		return false
+	}
+
+	writeFile(src, "package util\n\ntype Key string\n\ntype Getter interface {\n\tGet(key Key) Key\n}\n")
+	if generate() {
+		t.Error("generated at first, want not cached")
+	}
+	if !generate() {
+		t.Error("generated without changes, want cached")
+	}
+	// the zero value of the result is changed
+	writeFile(src, "package util\n\ntype Key int\n\ntype Getter interface {\n\tGet(key Key) Key\n}\n")
+	if generate() {
+		t.Error("generated after the type is changed, want not cached")
+	}
+	if !generate() {
+		t.Error("generated without changes, want cached")
+	}
+	writeFile(out, "package util\n")
+	if generate() {
+		t.Error("generated after the output is changed, want not cached")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"sort"
+	"strconv"
 
 	"golang.org/x/tools/go/packages"
 	goimports "golang.org/x/tools/imports"
@@ -21,6 +22,8 @@ type GoFile struct {
 
 	Package string
 	Import  *Import
+	// BuildConstraint is an expression of the //go:build line, which is omitted if empty.
+	BuildConstraint string
 }
 
 func NewGoFile() *GoFile {
@@ -32,6 +35,10 @@ func NewGoFile() *GoFile {
 
 func (f *GoFile) Generate() error {
 	buf := bytes.NewBuffer(nil)
+	if f.BuildConstraint != "" {
+		fmt.Fprintln(buf, `//go:build `+f.BuildConstraint)
+		fmt.Fprintln(buf)
+	}
 	fmt.Fprintln(buf, `package `, f.Package)
 	if err := f.Import.WriteTo(buf); err != nil {
 		return err
@@ -50,7 +57,7 @@ func (f *GoFile) Format() error {
 		return fmt.Errorf("failed to format by fomat: %w", err)
 	}
 	// remove not used packages in imported
-	b, err = goimports.Process("", b, &goimports.Options{})
+	b, err = goimports.Process("", b, &goimports.Options{Comments: true, TabIndent: true, TabWidth: 8})
 	if err != nil {
 		return fmt.Errorf("failed to format by goimports: %w", err)
 	}
@@ -98,28 +105,43 @@ func (f *GoFile) Check() error {
 }
 
 type Import struct {
-	importsCheck map[string]struct{}
-	imports      []string
+	importsCheck map[importSpec]struct{}
+	imports      []importSpec
+}
+
+// importSpec is an import of the package path, which is named if name is not empty.
+type importSpec struct {
+	name string
+	path string
 }
 
 func (im *Import) WriteTo(w io.Writer) error {
 	// Sort package
 	sort.Sort(im)
 	fmt.Fprintln(w, `import (`)
-	for i := 0; i < im.Len(); i++ {
-		fmt.Fprintln(w, `"`+im.At(i)+`"`)
+	for _, spec := range im.imports {
+		if spec.name != "" {
+			fmt.Fprint(w, spec.name+` `)
+		}
+		fmt.Fprintln(w, strconv.Quote(spec.path))
 	}
 	fmt.Fprintln(w, `)`)
 	return nil
 }
 
 func (im *Import) Add(pkg string) {
-	if im.imports == nil {
-		im.importsCheck = make(map[string]struct{})
+	im.AddNamed("", pkg)
+}
+
+// AddNamed adds an import of pkg named name, such as an alias of the import in the source file.
+func (im *Import) AddNamed(name, pkg string) {
+	if im.importsCheck == nil {
+		im.importsCheck = make(map[importSpec]struct{})
 	}
-	if _, ok := im.importsCheck[pkg]; ok {
-		im.importsCheck[pkg] = struct{}{}
-		im.imports = append(im.imports, pkg)
+	spec := importSpec{name: name, path: pkg}
+	if _, ok := im.importsCheck[spec]; !ok {
+		im.importsCheck[spec] = struct{}{}
+		im.imports = append(im.imports, spec)
 	}
 }
 
@@ -130,7 +152,7 @@ func (im *Import) AddPackages(imports map[string]*packages.Package) {
 }
 
 func (im *Import) At(i int) string {
-	return im.imports[i]
+	return im.imports[i].path
 }
 
 func (im *Import) Len() int {
@@ -142,5 +164,8 @@ func (im *Import) Swap(i, j int) {
 }
 
 func (im *Import) Less(i, j int) bool {
-	return im.imports[i] < im.imports[j]
+	if im.imports[i].path != im.imports[j].path {
+		return im.imports[i].path < im.imports[j].path
+	}
+	return im.imports[i].name < im.imports[j].name
 }
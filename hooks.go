@@ -0,0 +1,159 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// hookCalledBuffer is the buffer size of a channel returned by XxxCalled,
+// calls made while it is full are signaled on the next call or XxxCalled.
+const hookCalledBuffer = 64
+
+// WithHooks generates helpers to control concurrent calls of each method,
+// BlockXxx blocks calls until the returned release func is called,
+// XxxCalled returns a channel which receives a value on each call,
+// and WaitForXxxCalls waits until the method is called n times.
+func WithHooks() Option {
+	return func(m *SimpleMock) {
+		m.hooks = true
+	}
+}
+
+// hookFields are unexported fields of the mock for hooks of the method.
+type hookFields struct {
+	count, signaled, notify, called, gate string
+}
+
+func newHookFields(method string) hookFields {
+	prefix := unexportName(method)
+	return hookFields{
+		count:    prefix + `Count`,
+		signaled: prefix + `Signaled`,
+		notify:   prefix + `Notify`,
+		called:   prefix + `Called`,
+		gate:     prefix + `Gate`,
+	}
+}
+
+func (m *SimpleMock) addHooks() error {
+	signal := types.NewChan(types.SendRecv, types.NewStruct(nil, nil))
+	for _, method := range m.methods {
+		hf := newHookFields(method.name)
+		for _, field := range []*Field{
+			NewField(hf.count, types.Typ[types.Int]),
+			NewField(hf.signaled, types.Typ[types.Int]),
+			NewField(hf.notify, signal),
+			NewField(hf.called, signal),
+			NewField(hf.gate, signal),
+		} {
+			if err := m.structGenerator.AddField(field); err != nil {
+				return err
+			}
+		}
+
+		block := NewFunc(`Block`+method.name, FieldList{},
+			FieldList{NewField("release", types.NewSignatureType(nil, nil, nil, nil, nil, false))}, m.structGenerator, "m", false)
+		block.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			recvName := fn.RecvName()
+			fmt.Fprintln(w, `gate := make(chan struct{})`)
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, recvName+`.`+hf.gate+` = gate`)
+			fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+			fmt.Fprintln(w, `var once sync.Once`)
+			fmt.Fprintln(w, `return func() {`)
+			fmt.Fprintln(w, `once.Do(func() {`)
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, `if `+recvName+`.`+hf.gate+` == gate {`)
+			fmt.Fprintln(w, recvName+`.`+hf.gate+` = nil`)
+			fmt.Fprintln(w, `}`)
+			fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+			fmt.Fprintln(w, `close(gate)`)
+			fmt.Fprintln(w, `})`)
+			fmt.Fprintln(w, `}`)
+			return nil
+		})
+
+		called := NewFunc(method.name+`Called`, FieldList{},
+			FieldList{NewField("", types.NewChan(types.RecvOnly, types.NewStruct(nil, nil)))}, m.structGenerator, "m", false)
+		called.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			recvName := fn.RecvName()
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, `defer `+recvName+`.mu.Unlock()`)
+			writeCalledSignals(w, recvName, hf)
+			fmt.Fprintln(w, `return `+recvName+`.`+hf.called)
+			return nil
+		})
+
+		wait := NewFunc(`WaitFor`+method.name+`Calls`, FieldList{
+			NewField("n", types.Typ[types.Int]),
+			NewField("timeout", NewNamedType("time", "Duration", types.Typ[types.Int64])),
+		}, FieldList{NewField("", types.Typ[types.Bool])}, m.structGenerator, "m", false)
+		wait.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			recvName := fn.RecvName()
+			fmt.Fprintln(w, `timer := time.NewTimer(timeout)`)
+			fmt.Fprintln(w, `defer timer.Stop()`)
+			fmt.Fprintln(w, `for {`)
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, `if `+recvName+`.`+hf.count+` >= n {`)
+			fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+			fmt.Fprintln(w, `return true`)
+			fmt.Fprintln(w, `}`)
+			fmt.Fprintln(w, `if `+recvName+`.`+hf.notify+` == nil {`)
+			fmt.Fprintln(w, recvName+`.`+hf.notify+` = make(chan struct{})`)
+			fmt.Fprintln(w, `}`)
+			fmt.Fprintln(w, `notify := `+recvName+`.`+hf.notify)
+			fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+			fmt.Fprintln(w, `select {`)
+			fmt.Fprintln(w, `case <-notify:`)
+			fmt.Fprintln(w, `case <-timer.C:`)
+			fmt.Fprintln(w, `return false`)
+			fmt.Fprintln(w, `}`)
+			fmt.Fprintln(w, `}`)
+			return nil
+		})
+
+		m.funcGenerators = append(m.funcGenerators, block, called, wait)
+	}
+	return nil
+}
+
+// writeHooks writes statements which count the call, signal it, and wait for
+// the gate of the method to be released.
+func writeHooks(w io.Writer, recvName string, method *mockMethod) {
+	hf := newHookFields(method.name)
+	used := map[string]bool{recvName: true}
+	for _, fl := range []FieldList{method.params, method.results} {
+		for _, field := range fl {
+			used[field.Name()] = true
+		}
+	}
+	gateVar := uniqueName("gate", used)
+
+	fmt.Fprintln(w, recvName+`.mu.Lock()`)
+	fmt.Fprintln(w, recvName+`.`+hf.count+`++`)
+	fmt.Fprintln(w, `if `+recvName+`.`+hf.notify+` != nil {`)
+	fmt.Fprintln(w, `close(`+recvName+`.`+hf.notify+`)`)
+	fmt.Fprintln(w, recvName+`.`+hf.notify+` = nil`)
+	fmt.Fprintln(w, `}`)
+	writeCalledSignals(w, recvName, hf)
+	fmt.Fprintln(w, gateVar+` := `+recvName+`.`+hf.gate)
+	fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+	fmt.Fprintln(w, `if `+gateVar+` != nil {`)
+	fmt.Fprintln(w, `<-`+gateVar)
+	fmt.Fprintln(w, `}`)
+}
+
+// writeCalledSignals writes statements which send the signals of calls not signaled yet
+// to the channel of XxxCalled as long as it is not full, with the lock held.
+// The channel is created on the first call or XxxCalled, so that every call is signaled.
+func writeCalledSignals(w io.Writer, recvName string, hf hookFields) {
+	called := recvName + `.` + hf.called
+	fmt.Fprintln(w, `if `+called+` == nil {`)
+	fmt.Fprintf(w, "%s = make(chan struct{}, %d)\n", called, hookCalledBuffer)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w, `for `+recvName+`.`+hf.signaled+` < `+recvName+`.`+hf.count+` && len(`+called+`) < cap(`+called+`) {`)
+	fmt.Fprintln(w, called+` <- struct{}{}`)
+	fmt.Fprintln(w, recvName+`.`+hf.signaled+`++`)
+	fmt.Fprintln(w, `}`)
+}
@@ -1,43 +1,181 @@
 package simplemock
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/token"
 	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
 	"golang.org/x/tools/go/packages"
 )
 
-type loadFunc func(pkgname string, node ast.Node, info typeInfo, err error) error
+// loadedPackage is a loaded package with its files to generate from.
+type loadedPackage struct {
+	*packages.Package
+	// Files are syntax files of the package to generate from, which are only test files
+	// if test files are loaded.
+	Files []*ast.File
+	// Diagnostics are parse and type errors of the package.
+	Diagnostics []Diagnostic
+	// Err is set if the package can not be generated.
+	Err error
+}
 
-func load(patterns []string, f loadFunc) error {
-	var err error
+// dir returns the directory of the package.
+func (pkg *loadedPackage) dir() string {
+	if len(pkg.GoFiles) == 0 {
+		return ""
+	}
+	return filepath.Dir(pkg.GoFiles[0])
+}
+
+// load loads packages of opts.Patterns in the order of their IDs. Parse and type errors of
+// a package are returned as diagnostics, since types which do not refer to them can be generated,
+// but the package which can not be listed has an error.
+// The package is only parsed and not type-checked if opts.Syntax is set.
+// If opts.Tests is set, the test variant of the package and the external test package
+// are returned with their test files.
+func load(ctx context.Context, opts Options) ([]*loadedPackage, error) {
 	conf := &packages.Config{
-		Mode: packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports,
+		Dir:     opts.Dir,
+		Tests:   opts.Tests,
+	}
+	if tags := trimNames(opts.Tags); len(tags) > 0 {
+		conf.BuildFlags = []string{"-tags=" + strings.Join(tags, ",")}
 	}
-	loaded, err := packages.Load(conf, patterns...)
+	if opts.GOOS != "" || opts.GOARCH != "" {
+		conf.Env = os.Environ()
+		if opts.GOOS != "" {
+			conf.Env = append(conf.Env, "GOOS="+opts.GOOS)
+		}
+		if opts.GOARCH != "" {
+			conf.Env = append(conf.Env, "GOARCH="+opts.GOARCH)
+		}
+	}
+	if opts.Syntax {
+		conf.Mode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedSyntax
+	}
+	loaded, err := packages.Load(conf, opts.Patterns...)
 	if err != nil {
-		return fmt.Errorf("load package error: %w", err)
+		return nil, fmt.Errorf("load package error: %w", err)
+	}
+	if opts.Tests {
+		if loaded, err = testPackages(loaded); err != nil {
+			return nil, err
+		}
 	}
 	if len(loaded) == 0 {
-		return errors.New("not found package")
-	} else if len(loaded) > 1 {
-		return errors.New("you should only 1 package")
+		return nil, errors.New("not found package")
 	}
-	pkg := loaded[0]
-	for _, file := range pkg.Syntax {
-		err = f(pkg.Name, file, pkg.TypesInfo, err)
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].ID < loaded[j].ID })
+
+	var pkgs []*loadedPackage
+	for _, pkg := range loaded {
+		var diags, listDiags []Diagnostic
+		for _, e := range pkg.Errors {
+			d := Diagnostic{Pos: parsePosition(e.Pos), Message: e.Msg}
+			if e.Kind == packages.ListError {
+				listDiags = append(listDiags, d)
+			} else {
+				diags = append(diags, d)
+			}
+		}
+		if len(pkg.Syntax) == 0 || (pkg.Types == nil && !opts.Syntax) {
+			var msgs []string
+			for _, d := range append(listDiags, diags...) {
+				msgs = append(msgs, d.String())
+			}
+			err := fmt.Errorf("load package error: %s", strings.Join(msgs, "\n"))
+			pkgs = append(pkgs, &loadedPackage{Package: pkg, Err: err})
+			continue
+		}
+		if len(diags) == 0 {
+			// list errors repeat parse and type errors reported by the compiler
+			diags = listDiags
+		}
+		files := pkg.Syntax
+		if opts.Tests {
+			files = nil
+			for _, file := range pkg.Syntax {
+				if strings.HasSuffix(pkg.Fset.Position(file.Package).Filename, "_test.go") {
+					files = append(files, file)
+				}
+			}
+		}
+		pkgs = append(pkgs, &loadedPackage{Package: pkg, Files: files, Diagnostics: diags})
 	}
+	return pkgs, nil
+}
 
-	return nil
+// testPackages returns test variants of packages and their external test packages,
+// which are loaded with the packages and the test main packages.
+func testPackages(loaded []*packages.Package) ([]*packages.Package, error) {
+	var pkgs []*packages.Package
+	for _, pkg := range loaded {
+		// IDs of test packages are suffixed by the test main package, such as "p_test [p.test]"
+		if strings.Contains(pkg.ID, " [") {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	if len(pkgs) == 0 && len(loaded) > 0 {
+		return nil, errors.New("not found test files")
+	}
+	return pkgs, nil
+}
+
+// parsePosition parses a position formatted as file:line:column or file:line.
+func parsePosition(s string) token.Position {
+	var pos token.Position
+	parts := strings.Split(s, ":")
+	var nums []int
+	for len(parts) > 1 && len(nums) < 2 {
+		n, err := strconv.Atoi(parts[len(parts)-1])
+		if err != nil {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		parts = parts[:len(parts)-1]
+	}
+	if len(nums) == 0 {
+		return pos
+	}
+	pos.Filename = strings.Join(parts, ":")
+	pos.Line = nums[0]
+	if len(nums) > 1 {
+		pos.Column = nums[1]
+	}
+	return pos
+}
+
+// checkType returns a diagnostic if the declared type refers to types which have errors,
+// then it must not be generated.
+func checkType(pkg *packages.Package, name string, typ types.Type) *Diagnostic {
+	if !hasInvalidType(typ) {
+		return nil
+	}
+	d := &Diagnostic{Message: fmt.Sprintf("%s is not generated since it refers to types which have errors", name)}
+	if obj := pkg.Types.Scope().Lookup(name); obj != nil {
+		d.Pos = pkg.Fset.Position(obj.Pos())
+	}
+	return d
 }
 
 type typeInfo interface {
 	TypeOf(e ast.Expr) types.Type
 }
 
-type walkFunc func(iface string, ifaceType *types.Interface, err error) error
+// walkFunc is called with an exported type declaration, typ is *types.Interface
+// for an interface type or *types.Signature for a func type.
+type walkFunc func(name string, typ types.Type, err error) error
 
 func walk(node ast.Node, info typeInfo, f walkFunc) error {
 	var err error
@@ -48,10 +186,19 @@ func walk(node ast.Node, info typeInfo, f walkFunc) error {
 			if t.Name.IsExported() {
 				switch v := t.Type.(type) {
 				case *ast.InterfaceType:
-					ifaceType, ok := info.TypeOf(v).Underlying().(*types.Interface)
+					typ := info.TypeOf(v)
+					if typ == nil {
+						return true
+					}
+					ifaceType, ok := typ.Underlying().(*types.Interface)
 					if ok {
 						err = f(t.Name.Name, ifaceType, err)
 					}
+				case *ast.FuncType:
+					sig, ok := info.TypeOf(v).(*types.Signature)
+					if ok {
+						err = f(t.Name.Name, sig, err)
+					}
 				}
 			}
 		}
@@ -60,3 +207,42 @@ func walk(node ast.Node, info typeInfo, f walkFunc) error {
 
 	return err
 }
+
+// extractNotFound returns an error of names to extract which are not found as concrete types.
+func extractNotFound(names, found map[string]bool) error {
+	var missing []string
+	for name := range names {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("not found concrete types to extract: %s", strings.Join(missing, ", "))
+}
+
+// walkExtract calls f with an interface of the exported method set of the pointer
+// to each named type in names.
+func walkExtract(node ast.Node, info typeInfo, names map[string]bool, f walkFunc) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch t := node.(type) {
+		case *ast.TypeSpec:
+			if !names[t.Name.Name] {
+				return true
+			}
+			if _, ok := t.Type.(*ast.InterfaceType); ok {
+				return true
+			}
+			named, ok := info.TypeOf(t.Name).(*types.Named)
+			if ok {
+				err = f(t.Name.Name, ExtractInterface(named), err)
+			}
+		}
+		return true
+	})
+
+	return err
+}
@@ -0,0 +1,296 @@
+package simplemock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// Model is a data model of a discovered interface or func type,
+// which is passed to templates and emitted as JSON by -emit model.
+type Model struct {
+	// Name is the name of the type.
+	Name string `json:"name"`
+	// Package and PackagePath are the name and the import path of the package which declares the type.
+	Package     string `json:"package"`
+	PackagePath string `json:"packagePath"`
+	// Kind is "interface" or "func".
+	Kind string `json:"kind"`
+	// Doc is the doc comment of the type.
+	Doc string `json:"doc,omitempty"`
+	// Pos is the source position of the type as file:line:column, which is empty if it is unknown.
+	Pos string `json:"pos,omitempty"`
+	// TypeParams are type parameters of the type, whose Type is the constraint.
+	TypeParams []Param `json:"typeParams,omitempty"`
+	// Methods are methods of the interface, or a method named Name of the func type.
+	Methods []Method `json:"methods"`
+	// Imports are import paths of packages referred by the methods.
+	Imports []string `json:"imports,omitempty"`
+}
+
+// Method is a method of a Model.
+type Method struct {
+	Name     string  `json:"name"`
+	Doc      string  `json:"doc,omitempty"`
+	Pos      string  `json:"pos,omitempty"`
+	Params   []Param `json:"params"`
+	Results  []Param `json:"results"`
+	Variadic bool    `json:"variadic,omitempty"`
+}
+
+// Param is a param or a result of a Method, or a type parameter of a Model.
+type Param struct {
+	// Name is the name of the param, which is empty if it is unnamed.
+	Name string `json:"name,omitempty"`
+	// Type is the type as written in the generated code.
+	Type string `json:"type"`
+	// QualifiedType is the type qualified by full import paths of packages.
+	QualifiedType string `json:"qualifiedType"`
+	// ImportPaths are import paths of packages referred by Type.
+	ImportPaths []string `json:"importPaths,omitempty"`
+	// Variadic reports whether the param is the variadic last param, whose Type is a slice.
+	Variadic bool `json:"variadic,omitempty"`
+
+	typ types.Type
+}
+
+// NewModel returns a model of the type, typ is *types.Interface or *types.Signature.
+// It returns nil for the other types. Positions of methods are set if fset is not nil.
+func NewModel(fset *token.FileSet, pkg *types.Package, name string, typ types.Type) *Model {
+	m := &Model{Name: name}
+	if pkg != nil {
+		m.Package, m.PackagePath = pkg.Name(), pkg.Path()
+	}
+	switch typ := typ.(type) {
+	case *types.Interface:
+		m.Kind = "interface"
+		for i := 0; i < typ.NumMethods(); i++ {
+			method := typ.Method(i)
+			mm := newMethod(method.Name(), method.Type().(*types.Signature))
+			mm.Pos = position(fset, method.Pos())
+			m.Methods = append(m.Methods, mm)
+		}
+	case *types.Signature:
+		m.Kind = "func"
+		m.Methods = append(m.Methods, newMethod(name, typ))
+	default:
+		return nil
+	}
+
+	imports := make(map[string]bool)
+	for _, method := range m.Methods {
+		for _, params := range [][]Param{method.Params, method.Results} {
+			for _, param := range params {
+				for _, path := range param.ImportPaths {
+					imports[path] = true
+				}
+			}
+		}
+	}
+	for path := range imports {
+		m.Imports = append(m.Imports, path)
+	}
+	sort.Strings(m.Imports)
+	return m
+}
+
+func newMethod(name string, sig *types.Signature) Method {
+	method := Method{Name: name, Params: []Param{}, Results: []Param{}, Variadic: sig.Variadic()}
+	for i := 0; i < sig.Params().Len(); i++ {
+		param := newParam(sig.Params().At(i).Name(), sig.Params().At(i).Type())
+		param.Variadic = sig.Variadic() && i == sig.Params().Len()-1
+		method.Params = append(method.Params, param)
+	}
+	for i := 0; i < sig.Results().Len(); i++ {
+		method.Results = append(method.Results, newParam(sig.Results().At(i).Name(), sig.Results().At(i).Type()))
+	}
+	return method
+}
+
+func newParam(name string, typ types.Type) Param {
+	return Param{
+		Name:          name,
+		Type:          TypeString(typ),
+		QualifiedType: types.TypeString(typ, func(pkg *types.Package) string { return pkg.Path() }),
+		ImportPaths:   importPaths(typ),
+		typ:           typ,
+	}
+}
+
+// position returns pos as file:line:column, or an empty string if it is unknown.
+func position(fset *token.FileSet, pos token.Pos) string {
+	if fset == nil || !pos.IsValid() {
+		return ""
+	}
+	return fset.Position(pos).String()
+}
+
+// importPaths returns sorted import paths of packages referred by typ.
+func importPaths(typ types.Type) []string {
+	seen := make(map[string]bool)
+	visitTypes(typ, func(t types.Type) {
+		if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+			seen[named.Obj().Pkg().Path()] = true
+		}
+	})
+
+	var paths []string
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// walkModels calls f with a model of each exported interface and func type declared in node,
+// which has doc comments, type parameters and positions, and the type.
+func walkModels(fset *token.FileSet, node ast.Node, info *types.Info, f func(m *Model, typ types.Type) error) error {
+	var err error
+	ast.Inspect(node, func(node ast.Node) bool {
+		decl, ok := node.(*ast.GenDecl)
+		if !ok || err != nil {
+			return true
+		}
+		for _, spec := range decl.Specs {
+			t, ok := spec.(*ast.TypeSpec)
+			if !ok || !t.Name.IsExported() {
+				continue
+			}
+			obj := info.Defs[t.Name]
+			if obj == nil {
+				continue
+			}
+			var typ types.Type
+			switch t.Type.(type) {
+			case *ast.InterfaceType:
+				typ = obj.Type().Underlying()
+			case *ast.FuncType:
+				typ = obj.Type().Underlying()
+			default:
+				continue
+			}
+			m := NewModel(fset, obj.Pkg(), t.Name.Name, typ)
+			if m == nil {
+				continue
+			}
+			m.Pos = position(fset, t.Name.Pos())
+			m.Doc = docText(t.Doc)
+			if m.Doc == "" && len(decl.Specs) == 1 {
+				m.Doc = docText(decl.Doc)
+			}
+			if named, ok := obj.Type().(*types.Named); ok {
+				tparams := named.TypeParams()
+				for i := 0; i < tparams.Len(); i++ {
+					tparam := tparams.At(i)
+					m.TypeParams = append(m.TypeParams, newParam(tparam.Obj().Name(), tparam.Constraint()))
+				}
+			}
+			if iface, ok := t.Type.(*ast.InterfaceType); ok {
+				docs := make(map[string]string)
+				for _, field := range iface.Methods.List {
+					for _, name := range field.Names {
+						docs[name.Name] = docText(field.Doc)
+					}
+				}
+				for i := range m.Methods {
+					m.Methods[i].Doc = docs[m.Methods[i].Name]
+				}
+			}
+			if err = f(m, typ); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	return err
+}
+
+func docText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// packageModels returns models of opts.Types of the package, or of all exported interface
+// and func types if it is empty, and diagnostics.
+func packageModels(ctx context.Context, opts Options, pkg *loadedPackage) ([]*Model, []Diagnostic, error) {
+	if pkg.Err != nil {
+		return nil, nil, pkg.Err
+	}
+	typeNames := nameSet(opts.Types)
+	extractNames := nameSet(opts.Extract)
+
+	var models []*Model
+	var typeDiags []Diagnostic
+	extracted := make(map[string]bool)
+	add := func(m *Model, typ types.Type) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if len(typeNames) > 0 && !typeNames[m.Name] {
+			return nil
+		}
+		if d := checkType(pkg.Package, m.Name, typ); d != nil {
+			typeDiags = append(typeDiags, *d)
+			return nil
+		}
+		models = append(models, m)
+		return nil
+	}
+	for _, node := range pkg.Files {
+		var err error
+		if len(extractNames) == 0 {
+			err = walkModels(pkg.Fset, node, pkg.TypesInfo, add)
+		} else {
+			err = walkExtract(node, pkg.TypesInfo, extractNames, func(name string, typ types.Type, err error) error {
+				extracted[name] = true
+				if err != nil {
+					return err
+				}
+				m := NewModel(pkg.Fset, pkg.Types, name, typ)
+				if obj := pkg.Types.Scope().Lookup(name); obj != nil {
+					m.Pos = position(pkg.Fset, obj.Pos())
+				}
+				return add(m, typ)
+			})
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(extractNames) > 0 {
+		if err := extractNotFound(extractNames, extracted); err != nil {
+			return nil, nil, err
+		}
+	}
+	diags := append(append([]Diagnostic(nil), pkg.Diagnostics...), typeDiags...)
+	return models, diags, nil
+}
+
+// generateModel returns models of the loaded types of each package as JSON.
+func generateModel(ctx context.Context, opts Options) ([]GeneratedFile, error) {
+	if opts.Syntax {
+		return nil, errors.New("models can not be generated from syntax only")
+	}
+	return generatePackages(ctx, opts, func(ctx context.Context, pkg *loadedPackage, path string) (GeneratedFile, error) {
+		models, diags, err := packageModels(ctx, opts, pkg)
+		if err != nil {
+			return GeneratedFile{}, err
+		}
+		if models == nil {
+			models = []*Model{}
+		}
+		b, err := json.MarshalIndent(models, "", "  ")
+		if err != nil {
+			return GeneratedFile{}, fmt.Errorf("marshal models: %w", err)
+		}
+		return GeneratedFile{Path: path, Package: pkg.Name, Source: append(b, '\n'), Diagnostics: diags}, nil
+	})
+}
@@ -0,0 +1,67 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// Nop generates an implementation of the interface whose methods do nothing
+// and return zero values, which is used as a default in production code.
+type Nop struct {
+	name string
+
+	structGenerator *Struct
+	funcGenerators  []*Func
+}
+
+func NewNop(name string, interFace *types.Interface) (*Nop, error) {
+	structGenerator := NewStruct(name, FieldList{})
+
+	var funcGenerators []*Func
+	for i := 0; i < interFace.NumMethods(); i++ {
+		method := interFace.Method(i)
+		sig := method.Type().(*types.Signature)
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, "", sig.Variadic())
+		funcGenerator.ValueReceiver()
+		funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			if fn.Results().Len() != 0 {
+				fmt.Fprintln(w, fn.Results().Format(FormatReturnZeroValueResults))
+			}
+			return nil
+		})
+		funcGenerators = append(funcGenerators, funcGenerator)
+	}
+
+	return &Nop{
+		name:            name,
+		structGenerator: structGenerator,
+		funcGenerators:  funcGenerators,
+	}, nil
+}
+
+func (n *Nop) Name() string {
+	return n.name
+}
+
+func (n *Nop) WriteTo(w io.Writer) error {
+	if err := n.structGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate struct: %w", err)
+	}
+	for _, fg := range n.funcGenerators {
+		fmt.Fprintln(w)
+		if err := fg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate func: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+)
+
+// PartialMock generates a struct which embeds an implementation of the interface,
+// and intercepts calls of exported methods whose XxxFunc is set. Other methods,
+// including unexported ones, are promoted from the embedded implementation.
+type PartialMock struct {
+	name string
+
+	structGenerator *Struct
+	funcGenerators  []*Func
+}
+
+func NewPartialMock(name string, iface *types.TypeName, interFace *types.Interface) (*PartialMock, error) {
+	structGenerator := NewStruct(name, FieldList{})
+	if err := structGenerator.AddField(NewField("", namedType(iface, interFace))); err != nil {
+		return nil, fmt.Errorf("add field to struct: %w", err)
+	}
+
+	var funcGenerators []*Func
+	for i := 0; i < interFace.NumMethods(); i++ {
+		method := interFace.Method(i)
+		if !token.IsExported(method.Name()) {
+			continue
+		}
+		if method.Name() == iface.Name() {
+			return nil, fmt.Errorf("method %s conflicts with the embedded field %s", method.Name(), iface.Name())
+		}
+		sig := method.Type().(*types.Signature)
+		fieldName := method.Name() + `Func`
+		if err := structGenerator.AddField(NewField(fieldName, sig)); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		params = params.WithNames("arg")
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+
+		used := make(map[string]bool)
+		for _, fl := range []FieldList{params, results} {
+			for _, field := range fl {
+				used[field.Name()] = true
+			}
+		}
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, uniqueName("m", used), sig.Variadic())
+		funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			return writePartialBlock(fn, w, iface.Name(), fieldName)
+		})
+		funcGenerators = append(funcGenerators, funcGenerator)
+	}
+
+	m := &PartialMock{
+		name:            name,
+		structGenerator: structGenerator,
+		funcGenerators:  funcGenerators,
+	}
+	return m, nil
+}
+
+func writePartialBlock(fn *Func, w io.Writer, iface, fieldName string) error {
+	recvName := fn.RecvName()
+	args := fn.Params().Format(FormatInputParams)
+	if fn.Variadic() {
+		args = fn.Params().Format(FormatInputParamsWithVariadic)
+	}
+
+	if fn.Results().Len() == 0 {
+		fmt.Fprintln(w, `if `+recvName+`.`+fieldName+` != nil {`)
+		fmt.Fprintln(w, recvName+`.`+fieldName+args)
+		fmt.Fprintln(w, `return`)
+		fmt.Fprintln(w, `}`)
+		fmt.Fprintln(w, recvName+`.`+iface+`.`+fn.Name()+args)
+		return nil
+	}
+	fmt.Fprintln(w, `if `+recvName+`.`+fieldName+` != nil {`)
+	fmt.Fprintln(w, `return `+recvName+`.`+fieldName+args)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w, `return `+recvName+`.`+iface+`.`+fn.Name()+args)
+	return nil
+}
+
+func (m *PartialMock) Name() string {
+	return m.name
+}
+
+func (m *PartialMock) WriteTo(w io.Writer) error {
+	if err := m.structGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate struct: %w", err)
+	}
+	for _, fg := range m.funcGenerators {
+		fmt.Fprintln(w)
+		if err := fg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate func: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Plugin extends mocks generated by SimpleMock, such as assertions of a tracing library.
+// Plugins are registered by RegisterPlugin, and enabled by -plugins or WithPlugin.
+type Plugin interface {
+	// Name returns the name of the plugin, which is used by -plugins.
+	Name() string
+	// Imports returns import paths of packages which goimports can not resolve.
+	Imports() []string
+	// Fields returns extra fields of the mock of the interface.
+	Fields(mock string, iface *types.Interface) ([]*Field, error)
+	// WriteMethod writes statements at the beginning of the body of each method,
+	// which run after the call is recorded and before the stub is called.
+	WriteMethod(fn *Func, w io.Writer) error
+	// WriteAfterCall writes statements which run after the stub, the delegate or the zero values
+	// are returned, in a deferred function where the results of fn are named and can be read or set.
+	// A panic of the call is not recovered yet. Nothing is deferred if no statements are written.
+	WriteAfterCall(fn *Func, w io.Writer) error
+	// Methods returns extra methods of the mock, whose receiver is recv.
+	Methods(recv Receiver, iface *types.Interface) ([]*Func, error)
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Plugin)
+)
+
+// RegisterPlugin makes the plugin available by its name.
+// It panics if a plugin of the same name is already registered.
+func RegisterPlugin(p Plugin) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := plugins[p.Name()]; ok {
+		panic("simplemock: RegisterPlugin called twice for plugin " + p.Name())
+	}
+	plugins[p.Name()] = p
+}
+
+// WithPlugin enables the plugin.
+func WithPlugin(p Plugin) Option {
+	return func(m *SimpleMock) {
+		m.plugins = append(m.plugins, p)
+	}
+}
+
+// parsePlugins parses a comma separated list of registered plugin names.
+func parsePlugins(s string) ([]Option, error) {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+	var opts []Option
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p, ok := plugins[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown plugin %q, registered plugins are %s", name, strings.Join(pluginNames(), ", "))
+		}
+		opts = append(opts, WithPlugin(p))
+	}
+	return opts, nil
+}
+
+// pluginNames returns sorted names of registered plugins, the caller must hold pluginsMu.
+func pluginNames() []string {
+	var names []string
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *SimpleMock) addPlugins() error {
+	for _, p := range m.plugins {
+		fields, err := p.Fields(m.name, m.interFace)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+		for _, field := range fields {
+			if err := m.structGenerator.AddField(field); err != nil {
+				return fmt.Errorf("plugin %s: %w", p.Name(), err)
+			}
+		}
+		methods, err := p.Methods(m.structGenerator, m.interFace)
+		if err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+		m.funcGenerators = append(m.funcGenerators, methods...)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// Package replay records calls to a real implementation into a golden file
+// and replays them from it, which is used by mocks generated with -style replay.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type Mode int
+
+const (
+	// ModeReplay serves results from the golden file.
+	ModeReplay Mode = iota
+	// ModeRecord calls the real implementation and writes the golden file.
+	ModeRecord
+)
+
+// Codec serializes values of a type which encoding/json can not handle.
+type Codec interface {
+	Encode(v interface{}) (json.RawMessage, error)
+	// Decode stores the value into ptr, which is a pointer to the type.
+	Decode(data json.RawMessage, ptr interface{}) error
+}
+
+// Entry is a serialized call in the golden file.
+type Entry struct {
+	Method  string            `json:"method"`
+	Args    []json.RawMessage `json:"args"`
+	Results []json.RawMessage `json:"results"`
+}
+
+// Cassette holds the calls of a golden file.
+type Cassette struct {
+	tb     testing.TB
+	path   string
+	mode   Mode
+	codecs map[reflect.Type]Codec
+
+	mu      sync.Mutex
+	entries []*Entry
+	used    []bool
+}
+
+// New returns a Cassette for the golden file of path.
+// In ModeReplay the file is loaded immediately, in ModeRecord it is written when the test finished.
+func New(tb testing.TB, path string, mode Mode) *Cassette {
+	tb.Helper()
+	c := &Cassette{
+		tb:   tb,
+		path: path,
+		mode: mode,
+		codecs: map[reflect.Type]Codec{
+			reflect.TypeOf((*error)(nil)).Elem(): ErrorCodec{},
+		},
+	}
+	switch mode {
+	case ModeReplay:
+		b, err := os.ReadFile(path)
+		if err != nil {
+			tb.Fatalf("replay: load golden file: %v", err)
+		}
+		if err := json.Unmarshal(b, &c.entries); err != nil {
+			tb.Fatalf("replay: parse golden file %s: %v", path, err)
+		}
+		c.used = make([]bool, len(c.entries))
+	case ModeRecord:
+		tb.Cleanup(func() {
+			if err := c.save(); err != nil {
+				tb.Errorf("replay: save golden file: %v", err)
+			}
+		})
+	default:
+		tb.Fatalf("replay: unknown mode %d", mode)
+	}
+	return c
+}
+
+// RegisterCodec uses codec to serialize values of typ.
+func (c *Cassette) RegisterCodec(typ reflect.Type, codec Codec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.codecs[typ] = codec
+}
+
+// Recording reports whether the real implementation should be called.
+func (c *Cassette) Recording() bool {
+	return c.mode == ModeRecord
+}
+
+// Call starts a call of method, args are pointers to the arguments.
+// In ModeRecord the arguments are recorded, and in ModeReplay the first unused
+// call of method is looked up and the arguments must be equal to the recorded ones.
+func (c *Cassette) Call(method string, args ...interface{}) *Call {
+	c.tb.Helper()
+	encoded, err := c.encode(args)
+	if err != nil {
+		c.tb.Errorf("replay: %s: encode arguments: %v", method, err)
+		return &Call{cassette: c, method: method}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mode == ModeRecord {
+		entry := &Entry{Method: method, Args: encoded}
+		c.entries = append(c.entries, entry)
+		return &Call{cassette: c, method: method, entry: entry}
+	}
+
+	var mismatch *Entry
+	for i, entry := range c.entries {
+		if c.used[i] || entry.Method != method {
+			continue
+		}
+		if !equalArgs(entry.Args, encoded) {
+			if mismatch == nil {
+				mismatch = entry
+			}
+			continue
+		}
+		c.used[i] = true
+		return &Call{cassette: c, method: method, entry: entry}
+	}
+	if mismatch != nil {
+		c.tb.Errorf("replay: %s: arguments mismatch\n\trecorded: %s\n\tactual:   %s", method, joinRaw(mismatch.Args), joinRaw(encoded))
+	} else {
+		c.tb.Errorf("replay: %s: unexpected call with arguments %s", method, joinRaw(encoded))
+	}
+	return &Call{cassette: c, method: method}
+}
+
+// Call is a call started by Cassette.Call.
+type Call struct {
+	cassette *Cassette
+	method   string
+	entry    *Entry
+}
+
+// Results records the results in ModeRecord, or stores the recorded results
+// in ModeReplay, results are pointers to the results.
+func (call *Call) Results(results ...interface{}) {
+	c := call.cassette
+	c.tb.Helper()
+	if call.entry == nil {
+		return
+	}
+	if c.mode == ModeRecord {
+		encoded, err := c.encode(results)
+		if err != nil {
+			c.tb.Errorf("replay: %s: encode results: %v", call.method, err)
+			return
+		}
+		c.mu.Lock()
+		call.entry.Results = encoded
+		c.mu.Unlock()
+		return
+	}
+
+	if len(call.entry.Results) != len(results) {
+		c.tb.Errorf("replay: %s: recorded %d results, but %d results are expected", call.method, len(call.entry.Results), len(results))
+		return
+	}
+	for i, ptr := range results {
+		if err := c.decode(call.entry.Results[i], ptr); err != nil {
+			c.tb.Errorf("replay: %s: decode result %d: %v", call.method, i, err)
+		}
+	}
+}
+
+func (c *Cassette) codec(typ reflect.Type) (Codec, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	codec, ok := c.codecs[typ]
+	return codec, ok
+}
+
+func (c *Cassette) encode(ptrs []interface{}) ([]json.RawMessage, error) {
+	encoded := make([]json.RawMessage, 0, len(ptrs))
+	for _, ptr := range ptrs {
+		v := reflect.ValueOf(ptr).Elem()
+		var (
+			b   []byte
+			err error
+		)
+		if codec, ok := c.codec(v.Type()); ok {
+			b, err = codec.Encode(v.Interface())
+		} else {
+			b, err = json.Marshal(v.Interface())
+		}
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, b)
+	}
+	return encoded, nil
+}
+
+func (c *Cassette) decode(data json.RawMessage, ptr interface{}) error {
+	typ := reflect.TypeOf(ptr).Elem()
+	if codec, ok := c.codec(typ); ok {
+		return codec.Decode(data, ptr)
+	}
+	if typ.Kind() == reflect.Interface && !bytes.Equal(data, []byte("null")) {
+		return fmt.Errorf("no codec is registered for %s", typ)
+	}
+	return json.Unmarshal(data, ptr)
+}
+
+func (c *Cassette) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(b, '\n'), 0644)
+}
+
+func equalArgs(a, b []json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		var va, vb interface{}
+		if json.Unmarshal(a[i], &va) != nil || json.Unmarshal(b[i], &vb) != nil {
+			return false
+		}
+		if !reflect.DeepEqual(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
+func joinRaw(raws []json.RawMessage) string {
+	b, _ := json.Marshal(raws)
+	return string(b)
+}
+
+// ErrorCodec serializes an error as its message.
+type ErrorCodec struct{}
+
+func (ErrorCodec) Encode(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(v.(error).Error())
+}
+
+func (ErrorCodec) Decode(data json.RawMessage, ptr interface{}) error {
+	var msg *string
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	if msg != nil {
+		*ptr.(*error) = errors.New(*msg)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package replay_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/theoden9014/simplemock/replay"
+)
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, format)
+}
+
+func TestCassette(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden.json")
+
+	t.Run("record", func(t *testing.T) {
+		c := replay.New(t, path, replay.ModeRecord)
+		key, n, err := "a", 1, errors.New("not found")
+		c.Call("Get", &key).Results(&n, &err)
+		key, n, err = "b", 2, nil
+		c.Call("Get", &key).Results(&n, &err)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		c := replay.New(t, path, replay.ModeReplay)
+		for _, want := range []struct {
+			key string
+			n   int
+			err string
+		}{
+			{key: "b", n: 2},
+			{key: "a", n: 1, err: "not found"},
+		} {
+			var (
+				n   int
+				err error
+			)
+			c.Call("Get", &want.key).Results(&n, &err)
+			if n != want.n {
+				t.Errorf("Get(%q) n = %d, want %d", want.key, n, want.n)
+			}
+			if (err == nil && want.err != "") || (err != nil && err.Error() != want.err) {
+				t.Errorf("Get(%q) err = %v, want %q", want.key, err, want.err)
+			}
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		tb := &fakeTB{TB: t}
+		c := replay.New(tb, path, replay.ModeReplay)
+		key, n := "c", 0
+		c.Call("Get", &key).Results(&n)
+		if len(tb.errors) != 1 {
+			t.Errorf("got %d errors, want 1", len(tb.errors))
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+const replayPkgPath = "github.com/theoden9014/simplemock/replay"
+
+// ReplayMock generates a mock which records calls to a Delegate into a golden file,
+// or replays them from the golden file by a replay.Cassette.
+type ReplayMock struct {
+	name string
+
+	structGenerator *Struct
+	funcGenerators  []*Func
+}
+
+func NewReplayMock(name string, iface *types.TypeName, interFace *types.Interface) (*ReplayMock, error) {
+	cassette := types.NewPointer(NewNamedType(replayPkgPath, "Cassette", types.NewStruct(nil, nil)))
+	structGenerator := NewStruct(name, FieldList{})
+	for _, field := range []*Field{
+		NewField("Delegate", namedType(iface, interFace)),
+		NewField("Cassette", cassette),
+	} {
+		if err := structGenerator.AddField(field); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+	}
+
+	var funcGenerators []*Func
+	for i := 0; i < interFace.NumMethods(); i++ {
+		method := interFace.Method(i)
+		sig := method.Type().(*types.Signature)
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		params = params.WithNames("arg")
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+		results = results.WithNames("r")
+
+		used := make(map[string]bool)
+		for _, fl := range []FieldList{params, results} {
+			for _, field := range fl {
+				used[field.Name()] = true
+			}
+		}
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, uniqueName("m", used), sig.Variadic())
+		funcGenerator.SetBlockWriter(writeReplayBlock)
+		funcGenerators = append(funcGenerators, funcGenerator)
+	}
+
+	m := &ReplayMock{
+		name:            name,
+		structGenerator: structGenerator,
+		funcGenerators:  funcGenerators,
+	}
+	return m, nil
+}
+
+func writeReplayBlock(fn *Func, w io.Writer) error {
+	recvName := fn.RecvName()
+	params := fn.Params()
+	results := fn.Results()
+	args := params.Format(FormatInputParams)
+	if fn.Variadic() {
+		args = params.Format(FormatInputParamsWithVariadic)
+	}
+
+	used := map[string]bool{recvName: true}
+	var argPtrs, resultNames, resultPtrs []string
+	for _, field := range params {
+		used[field.Name()] = true
+		argPtrs = append(argPtrs, `&`+field.Name())
+	}
+	for _, field := range results {
+		used[field.Name()] = true
+		resultNames = append(resultNames, field.Name())
+		resultPtrs = append(resultPtrs, `&`+field.Name())
+	}
+	callVar := uniqueName("call", used)
+
+	fmt.Fprintln(w, callVar+` := `+recvName+`.Cassette.Call(`+strings.Join(append([]string{`"` + fn.Name() + `"`}, argPtrs...), `, `)+`)`)
+	fmt.Fprintln(w, `if `+recvName+`.Cassette.Recording() {`)
+	if results.Len() == 0 {
+		fmt.Fprintln(w, recvName+`.Delegate.`+fn.Name()+args)
+	} else {
+		fmt.Fprintln(w, strings.Join(resultNames, `, `)+` = `+recvName+`.Delegate.`+fn.Name()+args)
+	}
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w, callVar+`.Results(`+strings.Join(resultPtrs, `, `)+`)`)
+	fmt.Fprintln(w, strings.TrimSpace(`return `+strings.Join(resultNames, `, `)))
+	return nil
+}
+
+func (m *ReplayMock) Name() string {
+	return m.name
+}
+
+func (m *ReplayMock) Imports() []string {
+	return []string{replayPkgPath}
+}
+
+func (m *ReplayMock) WriteTo(w io.Writer) error {
+	if err := m.structGenerator.WriteTo(w); err != nil {
+		return fmt.Errorf("generate struct: %w", err)
+	}
+	for _, fg := range m.funcGenerators {
+		fmt.Fprintln(w)
+		if err := fg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate func: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+)
+
+// WithReset generates Reset which clears stubs and recorded calls,
+// and Snapshot and Restore which save and roll back them.
+// The names are suffixed by Mock if the interface has a method of the same name.
+func WithReset() Option {
+	return func(m *SimpleMock) {
+		m.reset = true
+	}
+}
+
+// helperName returns name of a helper method, which is suffixed by Mock
+// if the interface has a method of the same name.
+func (m *SimpleMock) helperName(name string) string {
+	for _, method := range m.methods {
+		if method.name == name {
+			return name + `Mock`
+		}
+	}
+	return name
+}
+
+// hasLock reports whether the mock has the mu field.
+func (m *SimpleMock) hasLock() bool {
+	return m.callLog || m.hooks || m.setters
+}
+
+// stateFields returns fields of the mock which are cleared by Reset, and saved by Snapshot.
+func (m *SimpleMock) stateFields() FieldList {
+	var fields FieldList
+	for _, field := range m.structGenerator.FieldList() {
+		switch {
+		case field.Name() == "mu":
+		case isHookField(field.Name(), m.methods):
+		default:
+			fields.Add(field)
+		}
+	}
+	return fields
+}
+
+func isHookField(name string, methods []*mockMethod) bool {
+	for _, method := range methods {
+		hf := newHookFields(method.name)
+		switch name {
+		case hf.count, hf.signaled, hf.notify, hf.called, hf.gate:
+			return true
+		}
+	}
+	return false
+}
+
+func (m *SimpleMock) addReset() error {
+	fields := m.stateFields()
+
+	snapshotName := m.name + `Snapshot`
+	snapshot := NewStruct(snapshotName, FieldList{})
+	for _, field := range fields {
+		if err := snapshot.AddField(NewField(unexportName(field.Name()), field.Type())); err != nil {
+			return err
+		}
+	}
+	snapshotType := NewNamedType("", snapshotName, snapshot.Type())
+
+	lock := func(w io.Writer, recvName string) {
+		if m.hasLock() {
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, `defer `+recvName+`.mu.Unlock()`)
+		}
+	}
+	// copy returns an expression of a copy of the value, slices are not shared.
+	copyExpr := func(typ types.Type, expr string) string {
+		if _, ok := typ.(*types.Slice); ok {
+			return `append(` + TypeString(typ) + `(nil), ` + expr + `...)`
+		}
+		return expr
+	}
+
+	reset := NewFunc(m.helperName(`Reset`), FieldList{}, FieldList{}, m.structGenerator, "m", false)
+	reset.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		recvName := fn.RecvName()
+		lock(w, recvName)
+		for _, field := range fields {
+			if field.Name() == "Delegate" {
+				continue
+			}
+			fmt.Fprintln(w, recvName+`.`+field.Name()+` = `+TypeZeroValue(field.Type()))
+		}
+		return nil
+	})
+
+	snap := NewFunc(m.helperName(`Snapshot`), FieldList{}, FieldList{NewField("", snapshotType)}, m.structGenerator, "m", false)
+	snap.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		recvName := fn.RecvName()
+		lock(w, recvName)
+		fmt.Fprintln(w, `return `+snapshotName+`{`)
+		for _, field := range fields {
+			fmt.Fprintln(w, unexportName(field.Name())+`: `+copyExpr(field.Type(), recvName+`.`+field.Name())+`,`)
+		}
+		fmt.Fprintln(w, `}`)
+		return nil
+	})
+
+	restore := NewFunc(m.helperName(`Restore`), FieldList{NewField("s", snapshotType)}, FieldList{}, m.structGenerator, "m", false)
+	restore.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		recvName := fn.RecvName()
+		lock(w, recvName)
+		for _, field := range fields {
+			fmt.Fprintln(w, recvName+`.`+field.Name()+` = `+copyExpr(field.Type(), `s.`+unexportName(field.Name())))
+		}
+		return nil
+	})
+
+	m.declGenerators = append(m.declGenerators, snapshot)
+	m.funcGenerators = append(m.funcGenerators, reset, snap, restore)
+	return nil
+}
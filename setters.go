@@ -0,0 +1,64 @@
+package simplemock
+
+import (
+	"fmt"
+	"io"
+)
+
+// WithSetters generates SetXxxFunc which sets a stub under the lock, and methods read
+// stubs and the Delegate under the lock, so that stubs can be replaced while the
+// mock is in use. The exported XxxFunc fields are still used if no stub is set.
+func WithSetters() Option {
+	return func(m *SimpleMock) {
+		m.setters = true
+	}
+}
+
+func (m *SimpleMock) addSetters() error {
+	for _, method := range m.methods {
+		field := stubField(method)
+		if err := m.structGenerator.AddField(NewField(field, method.sig)); err != nil {
+			return err
+		}
+
+		setter := NewFunc(m.helperName(`Set`+method.fieldName), FieldList{NewField("f", method.sig)}, FieldList{}, m.structGenerator, "m", false)
+		setter.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			recvName := fn.RecvName()
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, recvName+`.`+field+` = f`)
+			fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+			return nil
+		})
+		m.funcGenerators = append(m.funcGenerators, setter)
+	}
+	return nil
+}
+
+// writeStubLoad writes statements which read the stub and the Delegate under the lock,
+// and returns the names of variables which hold them.
+func (m *SimpleMock) writeStubLoad(w io.Writer, recvName string, method *mockMethod) (stub, delegate string) {
+	used := map[string]bool{recvName: true}
+	for _, fl := range []FieldList{method.params, method.results} {
+		for _, field := range fl {
+			used[field.Name()] = true
+		}
+	}
+	stub = uniqueName("stub", used)
+	used[stub] = true
+
+	fmt.Fprintln(w, recvName+`.mu.Lock()`)
+	fmt.Fprintln(w, stub+` := `+recvName+`.`+stubField(method))
+	fmt.Fprintln(w, `if `+stub+` == nil {`)
+	fmt.Fprintln(w, stub+` = `+recvName+`.`+method.fieldName)
+	fmt.Fprintln(w, `}`)
+	if m.delegate != nil {
+		delegate = uniqueName("delegate", used)
+		fmt.Fprintln(w, delegate+` := `+recvName+`.Delegate`)
+	}
+	fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+	return stub, delegate
+}
+
+func stubField(method *mockMethod) string {
+	return unexportName(method.name) + `Stub`
+}
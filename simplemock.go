@@ -1,29 +1,91 @@
 package simplemock
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"go/token"
 	"go/types"
 	"io"
 	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 type SimpleMock struct {
 	name      string
 	interFace *types.Interface
 
+	delegate    *types.TypeName
+	callLog     bool
+	constructor bool
+	hooks       bool
+	context     bool
+	reset       bool
+	setters     bool
+	assert      bool
+	transcript  bool
+	plugins     []Plugin
+
+	methods         []*mockMethod
 	structGenerator *Struct
+	callGenerators  []*Struct
 	funcGenerators  []*Func
+	declGenerators  []generator
 }
 
-func NewSimpleMock(name string, interFace *types.Interface) (*SimpleMock, error) {
+// mockMethod is a method of the interface which is implemented by the mock.
+type mockMethod struct {
+	name      string
+	fieldName string
+	sig       *types.Signature
+	params    FieldList
+	results   FieldList
+
+	call       *Struct // nil unless WithCallLog
+	callsField string
+}
+
+// Option configures optional behavior of a SimpleMock.
+type Option func(*SimpleMock)
+
+// WithDelegate adds a Delegate field of the named interface type,
+// unstubbed methods call through to it when it is not nil.
+func WithDelegate(iface *types.TypeName) Option {
+	return func(m *SimpleMock) {
+		m.delegate = iface
+	}
+}
+
+// WithCallLog records arguments, results and a recovered panic of every call.
+func WithCallLog() Option {
+	return func(m *SimpleMock) {
+		m.callLog = true
+	}
+}
+
+func NewSimpleMock(name string, interFace *types.Interface, opts ...Option) (*SimpleMock, error) {
+	m := &SimpleMock{
+		name:      name,
+		interFace: interFace,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
 	structGenerator := NewStruct(name, FieldList{})
-	var funcGenerators []*Func
+	m.structGenerator = structGenerator
+	if m.delegate != nil {
+		field := NewField("Delegate", namedType(m.delegate, interFace))
+		if err := structGenerator.AddField(field); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+	}
 
 	// all methods
 	for i := 0; i < interFace.NumMethods(); i++ {
 		method := interFace.Method(i)
-		method.Name()
 		sig := method.Type().(*types.Signature)
 		mockFieldName := method.Name() + `Func`
 		field := NewField(mockFieldName, sig)
@@ -35,36 +97,309 @@ func NewSimpleMock(name string, interFace *types.Interface) (*SimpleMock, error)
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
 		}
+		params = params.WithNames("arg")
 		results, err := NewFieldListFromType(sig.Results())
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
 		}
-		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, "m", sig.Variadic())
+		if m.callLog || len(m.plugins) > 0 {
+			// results are named to be captured by the deferred recorder and plugins
+			results = results.WithNames("r")
+		}
+		if _, ok := contextParam(params); ok && m.context {
+			if err := structGenerator.AddField(newHangField(method.Name())); err != nil {
+				return nil, fmt.Errorf("add field to struct: %w", err)
+			}
+		}
+
+		mm := &mockMethod{
+			name:      method.Name(),
+			fieldName: mockFieldName,
+			sig:       sig,
+			params:    params,
+			results:   results,
+		}
+		m.methods = append(m.methods, mm)
+		used := make(map[string]bool)
+		for _, field := range append(append(FieldList{}, params...), results...) {
+			used[field.Name()] = true
+		}
+		funcGenerator := NewFunc(method.Name(), params, results, structGenerator, uniqueName("m", used), sig.Variadic())
 		funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+			return m.writeMethodBlock(fn, w, mm)
+		})
+		m.funcGenerators = append(m.funcGenerators, funcGenerator)
+	}
+
+	if m.hasLock() {
+		if err := structGenerator.AddField(NewField("mu", NewNamedType("sync", "Mutex", types.NewStruct(nil, nil)))); err != nil {
+			return nil, fmt.Errorf("add field to struct: %w", err)
+		}
+	}
+	if m.callLog {
+		if err := m.addCallLog(); err != nil {
+			return nil, fmt.Errorf("generate call log: %w", err)
+		}
+	}
+	if m.transcript {
+		if err := m.addTranscript(); err != nil {
+			return nil, fmt.Errorf("generate transcript: %w", err)
+		}
+	}
+	if m.assert {
+		if err := m.addAssert(); err != nil {
+			return nil, fmt.Errorf("generate assertions: %w", err)
+		}
+	}
+	if m.setters {
+		if err := m.addSetters(); err != nil {
+			return nil, fmt.Errorf("generate setters: %w", err)
+		}
+	}
+	if len(m.plugins) > 0 {
+		if err := m.addPlugins(); err != nil {
+			return nil, err
+		}
+	}
+	if m.hooks {
+		if err := m.addHooks(); err != nil {
+			return nil, fmt.Errorf("generate hooks: %w", err)
+		}
+	}
+	if m.reset {
+		if err := m.addReset(); err != nil {
+			return nil, fmt.Errorf("generate reset: %w", err)
+		}
+	}
+	if m.constructor {
+		if err := m.addConstructor(); err != nil {
+			return nil, fmt.Errorf("generate constructor: %w", err)
+		}
+	}
+	if err := m.validateNames(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// validateNames reports names which are declared twice, since features add declarations by names
+// generated from names of methods. Fields and methods of the mock share their names,
+// and so do the other declarations.
+func (m *SimpleMock) validateNames() error {
+	members := make(map[string]bool)
+	for _, field := range m.structGenerator.FieldList() {
+		members[field.Name()] = true
+	}
+	for _, fn := range m.funcGenerators {
+		if members[fn.Name()] {
+			return fmt.Errorf("method %s of %s conflicts with another field or method", fn.Name(), m.name)
+		}
+		members[fn.Name()] = true
+	}
+	decls := map[string]bool{m.name: true}
+	var names []string
+	for _, cg := range m.callGenerators {
+		names = append(names, cg.Name())
+	}
+	for _, dg := range m.declGenerators {
+		if named, ok := dg.(interface{ Name() string }); ok {
+			names = append(names, named.Name())
+		}
+	}
+	for _, name := range names {
+		if decls[name] {
+			return fmt.Errorf("declaration %s conflicts with another declaration", name)
+		}
+		decls[name] = true
+	}
+	return nil
+}
+
+// addCallLog adds a call struct, a field of calls and an accessor of them for each method.
+func (m *SimpleMock) addCallLog() error {
+	var accessors []*Func
+	for _, method := range m.methods {
+		var extra FieldList
+		if m.assert {
+			extra = append(extra, NewField("Caller", types.Typ[types.String]))
+		}
+		call, err := newCallStruct(m.name+method.name+`Call`, method.params, method.results, extra)
+		if err != nil {
+			return err
+		}
+		m.callGenerators = append(m.callGenerators, call)
+		method.call = call
+		method.callsField = unexportName(method.name) + `Calls`
+		callsType := types.NewSlice(NewNamedType("", call.Name(), call.Type()))
+		if err := m.structGenerator.AddField(NewField(method.callsField, callsType)); err != nil {
+			return err
+		}
+
+		callsField := method.callsField
+		accessor := NewFunc(method.name+`Calls`, FieldList{}, FieldList{NewField("", callsType)}, m.structGenerator, "m", false)
+		accessor.SetBlockWriter(func(fn *Func, w io.Writer) error {
 			recvName := fn.RecvName()
-			fmt.Fprintln(w, `if `+recvName+`.`+mockFieldName+` != nil {`)
-			params := fn.Params()
-			if fn.Variadic() {
-				fmt.Fprintln(w, `return m.`+mockFieldName+params.Format(FormatInputParamsWithVariadic))
-			} else {
-				fmt.Fprintln(w, `return m.`+mockFieldName+params.Format(FormatInputParams))
-			}
-			fmt.Fprintln(w, `}`)
-			fmt.Fprintln(w, results.Format(FormatReturnZeroValueResults))
+			fmt.Fprintln(w, recvName+`.mu.Lock()`)
+			fmt.Fprintln(w, `defer `+recvName+`.mu.Unlock()`)
+			fmt.Fprintln(w, `return append(`+TypeString(callsType)+`(nil), `+recvName+`.`+callsField+`...)`)
 			return nil
 		})
-		funcGenerators = append(funcGenerators, funcGenerator)
+		accessors = append(accessors, accessor)
 	}
 
-	m := &SimpleMock{
-		name:            name,
-		interFace:       interFace,
-		structGenerator: structGenerator,
-		funcGenerators:  funcGenerators,
+	// place each accessor after the method
+	var funcGenerators []*Func
+	for i, fg := range m.funcGenerators {
+		funcGenerators = append(funcGenerators, fg, accessors[i])
+	}
+	m.funcGenerators = funcGenerators
+	return nil
+}
+
+// NewFuncMock returns a mock of the named func type, which records calls like
+// an interface with a single method of funcType, and whose Func method returns
+// the recording closure of funcType.
+func NewFuncMock(name string, funcType *types.TypeName, sig *types.Signature, opts ...Option) (*SimpleMock, error) {
+	method := types.NewFunc(token.NoPos, nil, funcType.Name(), sig)
+	m, err := NewSimpleMock(name, types.NewInterfaceType([]*types.Func{method}, nil).Complete(), append([]Option{WithCallLog()}, opts...)...)
+	if err != nil {
+		return nil, err
 	}
+	funcGenerator := NewFunc("Func", FieldList{}, FieldList{NewField("", namedType(funcType, sig))}, m.structGenerator, "m", false)
+	funcGenerator.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		fmt.Fprintln(w, `return `+fn.RecvName()+`.`+funcType.Name())
+		return nil
+	})
+	m.funcGenerators = append(m.funcGenerators, funcGenerator)
 	return m, nil
 }
 
+// writeMethodBlock writes the body of a mocked method, which calls the stub,
+// then the delegate if any, and otherwise returns zero values.
+func (m *SimpleMock) writeMethodBlock(fn *Func, w io.Writer, method *mockMethod) error {
+	recvName := fn.RecvName()
+	params := fn.Params()
+	results := fn.Results()
+	args := params.Format(FormatInputParams)
+	if fn.Variadic() {
+		args = params.Format(FormatInputParamsWithVariadic)
+	}
+	if method.call != nil {
+		m.writeCallRecorder(w, recvName, method, params, results)
+	}
+	if m.hooks {
+		writeHooks(w, recvName, method)
+	}
+	for _, p := range m.plugins {
+		if err := p.WriteMethod(fn, w); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	after := &bytes.Buffer{}
+	for _, p := range m.plugins {
+		if err := p.WriteAfterCall(fn, after); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	if after.Len() > 0 {
+		fmt.Fprintln(w, `defer func() {`)
+		after.WriteTo(w)
+		fmt.Fprintln(w, `}()`)
+	}
+	stub, delegate := recvName+`.`+method.fieldName, recvName+`.Delegate`
+	if m.setters {
+		stub, delegate = m.writeStubLoad(w, recvName, method)
+	}
+
+	writeCall := func(callee string) {
+		if results.Len() == 0 {
+			fmt.Fprintln(w, callee+args)
+			fmt.Fprintln(w, `return`)
+		} else {
+			fmt.Fprintln(w, `return `+callee+args)
+		}
+	}
+	fmt.Fprintln(w, `if `+stub+` != nil {`)
+	writeCall(stub)
+	fmt.Fprintln(w, `}`)
+	if m.delegate != nil {
+		fmt.Fprintln(w, `if `+delegate+` != nil {`)
+		writeCall(delegate + `.` + fn.Name())
+		fmt.Fprintln(w, `}`)
+	}
+	if ctx, ok := contextParam(params); ok && m.context {
+		writeContextBlock(w, recvName, ctx, method)
+	}
+	fmt.Fprintln(w, results.Format(FormatReturnZeroValueResults))
+	return nil
+}
+
+// newCallStruct returns a struct which holds a call of the method,
+// the fields are exported names of params and results, Panic and extra.
+func newCallStruct(name string, params, results, extra FieldList) (*Struct, error) {
+	call := NewStruct(name, FieldList{})
+	used := map[string]bool{"Panic": true}
+	for _, field := range extra {
+		used[field.Name()] = true
+	}
+	for _, fl := range []FieldList{params, results} {
+		for _, field := range fl {
+			fieldName := uniqueName(exportName(field.Name()), used)
+			used[fieldName] = true
+			if err := call.AddField(NewField(fieldName, field.Type())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := call.AddField(NewField("Panic", types.NewInterfaceType(nil, nil))); err != nil {
+		return nil, err
+	}
+	for _, field := range extra {
+		if err := call.AddField(field); err != nil {
+			return nil, err
+		}
+	}
+	return call, nil
+}
+
+// writeCallRecorder writes a deferred function which appends the call to the call log,
+// and to the transcript if WithTranscript.
+func (m *SimpleMock) writeCallRecorder(w io.Writer, recvName string, method *mockMethod, params, results FieldList) {
+	used := map[string]bool{recvName: true}
+	for _, fl := range []FieldList{params, results} {
+		for _, field := range fl {
+			used[field.Name()] = true
+		}
+	}
+	callVar := uniqueName("call", used)
+
+	call, callsField := method.call, method.callsField
+	fields := call.FieldList()
+	var elems []string
+	for i := 0; i < params.Len(); i++ {
+		elems = append(elems, fields.At(i).Name()+`: `+params.At(i).Name())
+	}
+	if m.assert {
+		elems = append(elems, `Caller: verify.Caller(1)`)
+	}
+	fmt.Fprintln(w, callVar+` := `+call.Name()+`{`+strings.Join(elems, `, `)+`}`)
+	fmt.Fprintln(w, `defer func() {`)
+	fmt.Fprintln(w, callVar+`.Panic = recover()`)
+	for i := 0; i < results.Len(); i++ {
+		fmt.Fprintln(w, callVar+`.`+fields.At(params.Len()+i).Name()+` = `+results.At(i).Name())
+	}
+	fmt.Fprintln(w, recvName+`.mu.Lock()`)
+	fmt.Fprintln(w, recvName+`.`+callsField+` = append(`+recvName+`.`+callsField+`, `+callVar+`)`)
+	if m.transcript {
+		writeTranscriptRecord(w, recvName, callVar, method)
+	}
+	fmt.Fprintln(w, recvName+`.mu.Unlock()`)
+	fmt.Fprintln(w, `if `+callVar+`.Panic != nil {`)
+	fmt.Fprintln(w, `panic(`+callVar+`.Panic)`)
+	fmt.Fprintln(w, `}`)
+	fmt.Fprintln(w, `}()`)
+}
+
 func (m *SimpleMock) Name() string {
 	return m.name
 }
@@ -73,12 +408,85 @@ func (m *SimpleMock) WriteTo(w io.Writer) error {
 	if err := m.structGenerator.WriteTo(w); err != nil {
 		return fmt.Errorf("generate struct: %w", err)
 	}
+	for _, cg := range m.callGenerators {
+		fmt.Fprintln(w)
+		if err := cg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate call struct: %w", err)
+		}
+	}
 	for _, fg := range m.funcGenerators {
 		fmt.Fprintln(w)
 		if err := fg.WriteTo(w); err != nil {
 			return fmt.Errorf("generate func: %w", err)
 		}
 	}
+	for _, dg := range m.declGenerators {
+		fmt.Fprintln(w)
+		if err := dg.WriteTo(w); err != nil {
+			return fmt.Errorf("generate declaration: %w", err)
+		}
+	}
+	return nil
+}
+
+// TypeDecl generates a type declaration.
+type TypeDecl struct {
+	name string
+	typ  types.Type
+}
+
+func NewTypeDecl(name string, typ types.Type) *TypeDecl {
+	return &TypeDecl{name: name, typ: typ}
+}
+
+func (d *TypeDecl) Name() string {
+	return d.name
+}
+
+func (d *TypeDecl) WriteTo(w io.Writer) error {
+	fmt.Fprintln(w, `type `+d.Name()+` `+TypeString(d.typ))
+	return nil
+}
+
+// Interface generates an interface declaration.
+type Interface struct {
+	name      string
+	interFace *types.Interface
+}
+
+func NewInterface(name string, interFace *types.Interface) *Interface {
+	return &Interface{name: name, interFace: interFace}
+}
+
+func (i *Interface) Name() string {
+	return i.name
+}
+
+func (i *Interface) WriteTo(w io.Writer) error {
+	fmt.Fprintln(w, `type `+i.Name()+` interface {`)
+	for j := 0; j < i.interFace.NumMethods(); j++ {
+		method := i.interFace.Method(j)
+		sig := method.Type().(*types.Signature)
+		params, err := NewFieldListFromType(sig.Params())
+		if err != nil {
+			return fmt.Errorf("failed to generate fields from types.Signature.Params(): %w", err)
+		}
+		results, err := NewFieldListFromType(sig.Results())
+		if err != nil {
+			return fmt.Errorf("failed to generate fields from types.Signature.Results(): %w", err)
+		}
+		var beforeResultsSpace string
+		if results.Len() != 0 {
+			beforeResultsSpace = " "
+		}
+		formatParams := FormatDeclarativeParams
+		if sig.Variadic() {
+			formatParams = FormatDeclarativeParamsWithVariadic
+		}
+		fmt.Fprintln(w, method.Name()+params.Format(formatParams)+beforeResultsSpace+results.Format(FormatDeclarativeResults))
+	}
+	fmt.Fprintln(w, `}`)
+
 	return nil
 }
 
@@ -97,10 +505,11 @@ func (s *Struct) Name() string {
 }
 
 func (s *Struct) AddField(field *Field) error {
-	s.fields.Add(field)
-	if err := s.fields.Validate(); err != nil {
+	fields := append(append(FieldList{}, s.fields...), field)
+	if err := fields.Validate(); err != nil {
 		return err
 	}
+	s.fields = fields
 	return nil
 }
 
@@ -176,13 +585,18 @@ func NewFieldListFromType(t types.Type) (FieldList, error) {
 }
 
 func (fl FieldList) Validate() error {
-	var checker map[string]bool
+	checker := make(map[string]bool)
 	for i := 0; i < fl.Len(); i++ {
 		field := fl.At(i)
 		fieldName := field.Name()
-		if none := checker[fieldName]; none {
-			return errors.New("there is a field with the same name")
+		// embedded fields and unnamed params
+		if fieldName == "" {
+			continue
+		}
+		if checker[fieldName] {
+			return fmt.Errorf("there is a field with the same name %s", fieldName)
 		}
+		checker[fieldName] = true
 	}
 	return nil
 }
@@ -201,6 +615,25 @@ func (fl *FieldList) Add(field *Field) {
 	*fl = append(*fl, field)
 }
 
+// WithNames returns a copy of the FieldList in which unnamed and blank fields
+// are named as prefix followed by their index.
+func (fl FieldList) WithNames(prefix string) FieldList {
+	used := make(map[string]bool)
+	for _, field := range fl {
+		used[field.Name()] = true
+	}
+	named := make(FieldList, 0, fl.Len())
+	for i, field := range fl {
+		name := field.Name()
+		if name == "" || name == "_" {
+			name = uniqueName(prefix+strconv.Itoa(i), used)
+			used[name] = true
+		}
+		named.Add(NewField(name, field.Type()))
+	}
+	return named
+}
+
 func (fl FieldList) At(i int) *Field {
 	return fl[i]
 }
@@ -224,18 +657,53 @@ func (fl FieldList) Format(formatter fieldListFormatter) (output string) {
 	return formatter(fl)
 }
 
+// uniqueName returns name, or name with a number suffix if it is already used.
+func uniqueName(name string, used map[string]bool) string {
+	if !used[name] {
+		return name
+	}
+	for i := 1; ; i++ {
+		if n := name + strconv.Itoa(i); !used[n] {
+			return n
+		}
+	}
+}
+
+func exportName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func unexportName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// Receiver is a named type which has methods, such as *Struct and *TypeDecl.
+type Receiver interface {
+	Name() string
+}
+
 type Func struct {
 	name          string
 	params        FieldList
 	results       FieldList
-	receiver      *Struct // todo: *Struct to abstract (type interface)
+	receiver      Receiver
 	receiverName  string
 	valueReceiver bool
 	blockWriter   func(*Func, io.Writer) error
 	variadic      bool
 }
 
-func NewFunc(name string, params FieldList, results FieldList, receiver *Struct, receiverName string, variadic bool) *Func {
+func NewFunc(name string, params FieldList, results FieldList, receiver Receiver, receiverName string, variadic bool) *Func {
 	fn := &Func{name: name, params: params, results: results, receiver: receiver, receiverName: receiverName, variadic: variadic}
 	return fn
 }
@@ -244,7 +712,7 @@ func (fn *Func) Name() string {
 	return fn.name
 }
 
-func (fn *Func) Recv() *Struct {
+func (fn *Func) Recv() Receiver {
 	return fn.receiver
 }
 
@@ -273,13 +741,16 @@ func (fn *Func) Variadic() bool {
 }
 
 func (fn *Func) WriteTo(w io.Writer) error {
-	// not support non receiver
-	if fn.receiver == nil {
-		return errors.New("(t.b.d) implement if non receiver in Func.WriteTo")
-	}
-	recvType := fn.Recv().Name()
-	if !fn.valueReceiver {
-		recvType = `*` + recvType
+	decl := `func ` + fn.Name()
+	if fn.receiver != nil {
+		recvType := fn.Recv().Name()
+		if !fn.valueReceiver {
+			recvType = `*` + recvType
+		}
+		if fn.RecvName() != "" {
+			recvType = fn.RecvName() + ` ` + recvType
+		}
+		decl = `func (` + recvType + `)` + ` ` + fn.Name()
 	}
 
 	var beforeResultsSpace string
@@ -292,10 +763,9 @@ func (fn *Func) WriteTo(w io.Writer) error {
 		if !ok {
 			return errors.New("variadic argument was expected but the last element is not sliced")
 		}
-		fn.params.Format(FormatDeclarativeParamsWithVariadic)
-		fmt.Fprintln(w, `func (`+fn.RecvName()+` `+recvType+`)`+` `+fn.Name()+fn.params.Format(FormatDeclarativeParamsWithVariadic)+beforeResultsSpace+fn.results.Format(FormatDeclarativeResults)+` {`)
+		fmt.Fprintln(w, decl+fn.params.Format(FormatDeclarativeParamsWithVariadic)+beforeResultsSpace+fn.results.Format(FormatDeclarativeResults)+` {`)
 	} else {
-		fmt.Fprintln(w, `func (`+fn.RecvName()+` `+recvType+`)`+` `+fn.Name()+fn.params.Format(FormatDeclarativeParams)+beforeResultsSpace+fn.results.Format(FormatDeclarativeResults)+` {`)
+		fmt.Fprintln(w, decl+fn.params.Format(FormatDeclarativeParams)+beforeResultsSpace+fn.results.Format(FormatDeclarativeResults)+` {`)
 	}
 
 	if fn.blockWriter != nil {
@@ -322,6 +792,37 @@ func FormatReturnZeroValueResults(fieldList FieldList) (output string) {
 	return output
 }
 
+// FormatReturnError returns a formatter of a return statement which returns errExpr
+// as the last error result, and zero values as the others.
+func FormatReturnError(errExpr string) fieldListFormatter {
+	return func(fieldList FieldList) (output string) {
+		errIndex := ErrorResultIndex(fieldList)
+		output += "return"
+		for i := 0; i < fieldList.Len(); i++ {
+			output += " "
+			if i == errIndex {
+				output += errExpr
+			} else {
+				output += TypeZeroValue(fieldList.At(i).Type())
+			}
+			if i != fieldList.Len()-1 {
+				output += ","
+			}
+		}
+		return output
+	}
+}
+
+// ErrorResultIndex returns the index of the last error in results, or -1.
+func ErrorResultIndex(results FieldList) int {
+	for i := results.Len() - 1; i >= 0; i-- {
+		if IsError(results.At(i).Type()) {
+			return i
+		}
+	}
+	return -1
+}
+
 func FormatInputParams(fieldList FieldList) (output string) {
 	if fieldList.Len() == 0 {
 		return "()"
@@ -403,7 +904,9 @@ func FormatDeclarativeParamsWithVariadic(fieldList FieldList) (output string) {
 				output += field.String()
 			} else {
 				elem := slice.Elem()
-				output += field.Name() + " "
+				if field.Name() != "" {
+					output += field.Name() + " "
+				}
 				output += "..." + TypeString(elem)
 			}
 		}
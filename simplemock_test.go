@@ -3,9 +3,11 @@ package simplemock
 import (
 	"bytes"
 	"fmt"
+	"go/token"
 	"go/types"
 	"io"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"golang.org/x/tools/go/packages"
@@ -62,12 +64,25 @@ id int64
 	}
 }
 
+func TestStruct_AddField(t *testing.T) {
+	s := NewStruct("User", FieldList{NewField("name", types.Typ[types.String])})
+	if err := s.AddField(NewField("name", types.Typ[types.Int64])); err == nil {
+		t.Error("AddField() of a field of the same name error = nil, want an error")
+	}
+	if err := s.AddField(NewField("", types.Typ[types.Int64])); err != nil {
+		t.Errorf("AddField() of an embedded field error = %v", err)
+	}
+	if got := s.FieldList().Len(); got != 2 {
+		t.Errorf("FieldList().Len() = %d, want 2", got)
+	}
+}
+
 func TestFunc_WriteTo(t *testing.T) {
 	type fields struct {
 		name          string
 		params        FieldList
 		results       FieldList
-		receiver      *Struct
+		receiver      Receiver
 		receiverName  string
 		valueReceiver bool
 		blockWriter   func(*Func, io.Writer) error
@@ -160,6 +175,21 @@ u.SetID(id)
 u.SetName(name)
 return true
 }
+`,
+			wantErr: false,
+		},
+		{
+			name: "non receiver",
+			fields: fields{
+				name: "NewUser",
+				params: FieldList{
+					NewField("name", types.Typ[types.String])},
+				results: FieldList{
+					NewField("", types.NewPointer(types.Typ[types.String]))},
+				blockWriter: nil,
+			},
+			wantW: `func NewUser(name string) *string {
+}
 `,
 			wantErr: false,
 		},
@@ -372,10 +402,94 @@ func TestFieldList_Format(t *testing.T) {
 	}
 }
 
+// spanPlugin is a plugin which records names of called methods as spans.
+type spanPlugin struct{}
+
+func (spanPlugin) Name() string { return "span" }
+
+func (spanPlugin) Imports() []string { return nil }
+
+func (spanPlugin) Fields(string, *types.Interface) ([]*Field, error) {
+	return []*Field{NewField("spans", types.NewSlice(types.Typ[types.String]))}, nil
+}
+
+func (spanPlugin) WriteMethod(fn *Func, w io.Writer) error {
+	fmt.Fprintln(w, fn.RecvName()+`.spans = append(`+fn.RecvName()+`.spans, "`+fn.Name()+`")`)
+	return nil
+}
+
+func (spanPlugin) WriteAfterCall(fn *Func, w io.Writer) error {
+	args := []string{`"` + fn.Name() + ` returned"`}
+	for _, result := range fn.Results() {
+		args = append(args, result.Name())
+	}
+	fmt.Fprintln(w, fn.RecvName()+`.spans = append(`+fn.RecvName()+`.spans, fmt.Sprint(`+strings.Join(args, `, `)+`))`)
+	return nil
+}
+
+func (spanPlugin) Methods(recv Receiver, _ *types.Interface) ([]*Func, error) {
+	fn := NewFunc("Spans", FieldList{}, FieldList{NewField("", types.NewSlice(types.Typ[types.String]))}, recv, "m", false)
+	fn.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		fmt.Fprintln(w, `return `+fn.RecvName()+`.spans`)
+		return nil
+	})
+	return []*Func{fn}, nil
+}
+
+func TestNewSimpleMock_Conflicts(t *testing.T) {
+	newInterface := func(names ...string) *types.Interface {
+		var methods []*types.Func
+		for _, name := range names {
+			sig := types.NewSignatureType(nil, nil, nil, nil, types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Typ[types.Int])), false)
+			methods = append(methods, types.NewFunc(token.NoPos, nil, name, sig))
+		}
+		return types.NewInterfaceType(methods, nil).Complete()
+	}
+	tests := []struct {
+		name    string
+		methods []string
+		opts    []Option
+		wantErr bool
+	}{
+		{
+			name:    "methods",
+			methods: []string{"Get", "GetReturns"},
+		},
+		{
+			// the option of results of Get and the option of GetReturns are WithStoreGetReturns
+			name:    "options",
+			methods: []string{"Get", "GetReturns"},
+			opts:    []Option{WithConstructor()},
+			wantErr: true,
+		},
+		{
+			name:    "method and field",
+			methods: []string{"Get", "GetFunc"},
+			wantErr: true,
+		},
+		{
+			name:    "hooks",
+			methods: []string{"Flush", "BlockFlush"},
+			opts:    []Option{WithHooks()},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSimpleMock("StoreMock", newInterface(tt.methods...), tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewSimpleMock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSimpleMock_WriteTo(t *testing.T) {
 	tests := []struct {
 		name    string
 		pkgpath string
+		style   string
+		opts    []Option
 		src     string
 		wantW   string
 		wantErr bool
@@ -416,7 +530,8 @@ return 0, nil
 
 func (m *BufferMock) Reset() {
 if m.ResetFunc != nil {
-return m.ResetFunc()
+m.ResetFunc()
+return
 }
 return
 }
@@ -430,36 +545,1133 @@ return 0, nil
 `,
 			wantErr: false,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			exported := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
-				{
-					Name: filepath.Dir(tt.pkgpath),
-					Files: map[string]interface{}{
-						filepath.Join(filepath.Base(tt.pkgpath), "x.go"): tt.src,
-					},
-				},
-			})
-			defer exported.Cleanup()
-			exported.Config.Mode = packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
-			pkgs, err := packages.Load(exported.Config, tt.pkgpath)
-			if err != nil {
-				t.Fatal(err)
-			}
-			if len(pkgs) != 1 {
-				t.Fatal("load package error")
-			}
-			pkg := pkgs[0]
-			for _, f := range pkg.Syntax {
-				err := walk(f, pkg.TypesInfo, func(iface string, ifaceType *types.Interface, err error) error {
-					if err != nil {
-						t.Fatal(err)
-					}
-					mockname := iface + "Mock"
-					mock, err := NewSimpleMock(mockname, ifaceType)
-					if err != nil {
-						t.Fatal(err)
+		{
+			name:    "spy",
+			pkgpath: "example.com/util",
+			style:   "spy",
+			src: `package util
+
+type Writer interface {
+	Write([]byte) error
+}
+`,
+			wantW: `type WriterSpy struct {
+Delegate Writer
+WriteFunc func([]byte) error
+mu sync.Mutex
+writeCalls []WriterSpyWriteCall
+}
+
+type WriterSpyWriteCall struct {
+Arg0 []byte
+R0 error
+Panic interface{}
+}
+
+func (m *WriterSpy) Write(arg0 []byte) (r0 error) {
+call := WriterSpyWriteCall{Arg0: arg0}
+defer func() {
+call.Panic = recover()
+call.R0 = r0
+m.mu.Lock()
+m.writeCalls = append(m.writeCalls, call)
+m.mu.Unlock()
+if call.Panic != nil {
+panic(call.Panic)
+}
+}()
+if m.WriteFunc != nil {
+return m.WriteFunc(arg0)
+}
+if m.Delegate != nil {
+return m.Delegate.Write(arg0)
+}
+return nil
+}
+
+func (m *WriterSpy) WriteCalls() []WriterSpyWriteCall {
+m.mu.Lock()
+defer m.mu.Unlock()
+return append([]WriterSpyWriteCall(nil), m.writeCalls...)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "replay",
+			pkgpath: "example.com/util",
+			style:   "replay",
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+	Flush()
+}
+`,
+			wantW: `type StoreReplay struct {
+Delegate Store
+Cassette *replay.Cassette
+}
+
+func (m *StoreReplay) Flush() {
+call := m.Cassette.Call("Flush")
+if m.Cassette.Recording() {
+m.Delegate.Flush()
+}
+call.Results()
+return
+}
+
+func (m *StoreReplay) Get(key string) (r0 string, r1 error) {
+call := m.Cassette.Call("Get", &key)
+if m.Cassette.Recording() {
+r0, r1 = m.Delegate.Get(key)
+}
+call.Results(&r0, &r1)
+return r0, r1
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "replay with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "replay",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerReplay struct {
+Delegate Doer
+Cassette *replay.Cassette
+}
+
+func (m1 *DoerReplay) Do(m int, f string) (r0 bool) {
+call := m1.Cassette.Call("Do", &m, &f)
+if m1.Cassette.Recording() {
+r0 = m1.Delegate.Do(m, f)
+}
+call.Results(&r0)
+return r0
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "func type",
+			pkgpath: "example.com/util",
+			src: `package util
+
+type Hook func(name string) error
+`,
+			wantW: `type HookMock struct {
+HookFunc func(name string) error
+mu sync.Mutex
+hookCalls []HookMockHookCall
+}
+
+type HookMockHookCall struct {
+Name string
+R0 error
+Panic interface{}
+}
+
+func (m *HookMock) Hook(name string) (r0 error) {
+call := HookMockHookCall{Name: name}
+defer func() {
+call.Panic = recover()
+call.R0 = r0
+m.mu.Lock()
+m.hookCalls = append(m.hookCalls, call)
+m.mu.Unlock()
+if call.Panic != nil {
+panic(call.Panic)
+}
+}()
+if m.HookFunc != nil {
+return m.HookFunc(name)
+}
+return nil
+}
+
+func (m *HookMock) HookCalls() []HookMockHookCall {
+m.mu.Lock()
+defer m.mu.Unlock()
+return append([]HookMockHookCall(nil), m.hookCalls...)
+}
+
+func (m *HookMock) Func() Hook {
+return m.Hook
+}
+`,
+			wantErr: false,
+		},
+		{
+			// the stub of a method without results is called as a statement, and not returned
+			name:    "method without results",
+			pkgpath: "example.com/util",
+			src: `package util
+
+type Flusher interface {
+	Flush()
+}
+`,
+			wantW: `type FlusherMock struct {
+FlushFunc func()
+}
+
+func (m *FlusherMock) Flush() {
+if m.FlushFunc != nil {
+m.FlushFunc()
+return
+}
+return
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "options",
+			pkgpath: "example.com/util",
+			opts:    []Option{WithConstructor()},
+			src: `package util
+
+type Closer interface {
+	Close() error
+}
+`,
+			wantW: `type CloserMock struct {
+CloseFunc func() error
+}
+
+func (m *CloserMock) Close() error {
+if m.CloseFunc != nil {
+return m.CloseFunc()
+}
+return nil
+}
+
+type CloserMockOption func(*CloserMock)
+
+func NewCloserMock(opts ...CloserMockOption) *CloserMock {
+m := &CloserMock{}
+for _, opt := range opts {
+opt(m)
+}
+return m
+}
+
+func WithCloserClose(f func() error) CloserMockOption {
+return func(m *CloserMock) {
+m.CloseFunc = f
+}
+}
+
+func WithCloserCloseReturns(r0 error) CloserMockOption {
+return func(m *CloserMock) {
+m.CloseFunc = func() error {
+return r0
+}
+}
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "options with results named as the receiver",
+			pkgpath: "example.com/util",
+			opts:    []Option{WithConstructor()},
+			src: `package util
+
+type Store interface {
+	Get(k string) (m int, f error)
+}
+`,
+			wantW: `type StoreMock struct {
+GetFunc func(k string) (m int, f error)
+}
+
+func (m1 *StoreMock) Get(k string) (m int, f error) {
+if m1.GetFunc != nil {
+return m1.GetFunc(k)
+}
+return 0, nil
+}
+
+type StoreMockOption func(*StoreMock)
+
+func NewStoreMock(opts ...StoreMockOption) *StoreMock {
+m := &StoreMock{}
+for _, opt := range opts {
+opt(m)
+}
+return m
+}
+
+func WithStoreGet(f func(k string) (m int, f error)) StoreMockOption {
+return func(m *StoreMock) {
+m.GetFunc = f
+}
+}
+
+func WithStoreGetReturns(m int, f error) StoreMockOption {
+return func(m1 *StoreMock) {
+m1.GetFunc = func(string) (int, error) {
+return m, f
+}
+}
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "hooks",
+			pkgpath: "example.com/util",
+			opts:    []Option{WithHooks()},
+			src: `package util
+
+type Flusher interface {
+	Flush()
+}
+`,
+			wantW: `type FlusherMock struct {
+FlushFunc func()
+mu sync.Mutex
+flushCount int
+flushSignaled int
+flushNotify chan struct{}
+flushCalled chan struct{}
+flushGate chan struct{}
+}
+
+func (m *FlusherMock) Flush() {
+m.mu.Lock()
+m.flushCount++
+if m.flushNotify != nil {
+close(m.flushNotify)
+m.flushNotify = nil
+}
+if m.flushCalled == nil {
+m.flushCalled = make(chan struct{}, 64)
+}
+for m.flushSignaled < m.flushCount && len(m.flushCalled) < cap(m.flushCalled) {
+m.flushCalled <- struct{}{}
+m.flushSignaled++
+}
+gate := m.flushGate
+m.mu.Unlock()
+if gate != nil {
+<-gate
+}
+if m.FlushFunc != nil {
+m.FlushFunc()
+return
+}
+return
+}
+
+func (m *FlusherMock) BlockFlush() (release func()) {
+gate := make(chan struct{})
+m.mu.Lock()
+m.flushGate = gate
+m.mu.Unlock()
+var once sync.Once
+return func() {
+once.Do(func() {
+m.mu.Lock()
+if m.flushGate == gate {
+m.flushGate = nil
+}
+m.mu.Unlock()
+close(gate)
+})
+}
+}
+
+func (m *FlusherMock) FlushCalled() <-chan struct{} {
+m.mu.Lock()
+defer m.mu.Unlock()
+if m.flushCalled == nil {
+m.flushCalled = make(chan struct{}, 64)
+}
+for m.flushSignaled < m.flushCount && len(m.flushCalled) < cap(m.flushCalled) {
+m.flushCalled <- struct{}{}
+m.flushSignaled++
+}
+return m.flushCalled
+}
+
+func (m *FlusherMock) WaitForFlushCalls(n int, timeout time.Duration) bool {
+timer := time.NewTimer(timeout)
+defer timer.Stop()
+for {
+m.mu.Lock()
+if m.flushCount >= n {
+m.mu.Unlock()
+return true
+}
+if m.flushNotify == nil {
+m.flushNotify = make(chan struct{})
+}
+notify := m.flushNotify
+m.mu.Unlock()
+select {
+case <-notify:
+case <-timer.C:
+return false
+}
+}
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "context",
+			pkgpath: "example.com/util",
+			opts:    []Option{WithContext()},
+			src: `package util
+
+import "context"
+
+type Querier interface {
+	Query(ctx context.Context, q string) (int, error)
+}
+`,
+			wantW: `type QuerierMock struct {
+QueryFunc func(ctx context.Context, q string) (int, error)
+QueryHang bool
+}
+
+func (m *QuerierMock) Query(ctx context.Context, q string) (int, error) {
+if m.QueryFunc != nil {
+return m.QueryFunc(ctx, q)
+}
+if m.QueryHang {
+<-ctx.Done()
+}
+if ctx.Err() != nil {
+return 0, ctx.Err()
+}
+return 0, nil
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "fault",
+			pkgpath: "example.com/util",
+			style:   "fault",
+			src: `package util
+
+import "context"
+
+type Querier interface {
+	Query(ctx context.Context, q string) (int, error)
+	Close()
+}
+`,
+			wantW: `type QuerierFault struct {
+Delegate Querier
+Injector *fault.Injector
+}
+
+func (m *QuerierFault) Close() {
+m.Injector.Inject(context.Background(), "Close")
+m.Delegate.Close()
+}
+
+func (m *QuerierFault) Query(ctx context.Context, q string) (int, error) {
+if err := m.Injector.Inject(ctx, "Query"); err != nil {
+return 0, err
+}
+return m.Delegate.Query(ctx, q)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "fault with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "fault",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerFault struct {
+Delegate Doer
+Injector *fault.Injector
+}
+
+func (m1 *DoerFault) Do(m int, f string) (r0 bool) {
+m1.Injector.Inject(context.Background(), "Do")
+return m1.Delegate.Do(m, f)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "reset",
+			pkgpath: "example.com/util",
+			style:   "spy",
+			opts:    []Option{WithReset()},
+			src: `package util
+
+type Closer interface {
+	Close() error
+}
+`,
+			wantW: `type CloserSpy struct {
+Delegate Closer
+CloseFunc func() error
+mu sync.Mutex
+closeCalls []CloserSpyCloseCall
+}
+
+type CloserSpyCloseCall struct {
+R0 error
+Panic interface{}
+}
+
+func (m *CloserSpy) Close() (r0 error) {
+call := CloserSpyCloseCall{}
+defer func() {
+call.Panic = recover()
+call.R0 = r0
+m.mu.Lock()
+m.closeCalls = append(m.closeCalls, call)
+m.mu.Unlock()
+if call.Panic != nil {
+panic(call.Panic)
+}
+}()
+if m.CloseFunc != nil {
+return m.CloseFunc()
+}
+if m.Delegate != nil {
+return m.Delegate.Close()
+}
+return nil
+}
+
+func (m *CloserSpy) CloseCalls() []CloserSpyCloseCall {
+m.mu.Lock()
+defer m.mu.Unlock()
+return append([]CloserSpyCloseCall(nil), m.closeCalls...)
+}
+
+func (m *CloserSpy) Reset() {
+m.mu.Lock()
+defer m.mu.Unlock()
+m.CloseFunc = nil
+m.closeCalls = nil
+}
+
+func (m *CloserSpy) Snapshot() CloserSpySnapshot {
+m.mu.Lock()
+defer m.mu.Unlock()
+return CloserSpySnapshot{
+delegate: m.Delegate,
+closeFunc: m.CloseFunc,
+closeCalls: append([]CloserSpyCloseCall(nil), m.closeCalls...),
+}
+}
+
+func (m *CloserSpy) Restore(s CloserSpySnapshot) {
+m.mu.Lock()
+defer m.mu.Unlock()
+m.Delegate = s.delegate
+m.CloseFunc = s.closeFunc
+m.closeCalls = append([]CloserSpyCloseCall(nil), s.closeCalls...)
+}
+
+type CloserSpySnapshot struct {
+delegate Closer
+closeFunc func() error
+closeCalls []CloserSpyCloseCall
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "setters",
+			pkgpath: "example.com/util",
+			style:   "mock",
+			opts:    []Option{WithSetters()},
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+}
+`,
+			wantW: `type StoreMock struct {
+GetFunc func(key string) (string, error)
+mu sync.Mutex
+getStub func(key string) (string, error)
+}
+
+func (m *StoreMock) Get(key string) (string, error) {
+m.mu.Lock()
+stub := m.getStub
+if stub == nil {
+stub = m.GetFunc
+}
+m.mu.Unlock()
+if stub != nil {
+return stub(key)
+}
+return "", nil
+}
+
+func (m *StoreMock) SetGetFunc(f func(key string) (string, error)) {
+m.mu.Lock()
+m.getStub = f
+m.mu.Unlock()
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "assert",
+			pkgpath: "example.com/util",
+			style:   "mock",
+			opts:    []Option{WithAssert()},
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+}
+`,
+			wantW: `type StoreMock struct {
+GetFunc func(key string) (string, error)
+mu sync.Mutex
+getCalls []StoreMockGetCall
+}
+
+type StoreMockGetCall struct {
+Key string
+R0 string
+R1 error
+Panic interface{}
+Caller string
+}
+
+func (m *StoreMock) Get(key string) (r0 string, r1 error) {
+call := StoreMockGetCall{Key: key, Caller: verify.Caller(1)}
+defer func() {
+call.Panic = recover()
+call.R0 = r0
+call.R1 = r1
+m.mu.Lock()
+m.getCalls = append(m.getCalls, call)
+m.mu.Unlock()
+if call.Panic != nil {
+panic(call.Panic)
+}
+}()
+if m.GetFunc != nil {
+return m.GetFunc(key)
+}
+return "", nil
+}
+
+func (m *StoreMock) GetCalls() []StoreMockGetCall {
+m.mu.Lock()
+defer m.mu.Unlock()
+return append([]StoreMockGetCall(nil), m.getCalls...)
+}
+
+func (m *StoreMock) AssertGetCalls(tb testing.TB, n int) {
+tb.Helper()
+calls := m.GetCalls()
+callers := make([]string, len(calls))
+for j, c := range calls {
+callers[j] = c.Caller
+}
+verify.Count(tb, "StoreMock.Get", callers, n)
+}
+
+func (m *StoreMock) AssertGetCalledWith(tb testing.TB, i int, key string) {
+tb.Helper()
+calls := m.GetCalls()
+callers := make([]string, len(calls))
+for j, c := range calls {
+callers[j] = c.Caller
+}
+var args []verify.Arg
+if i >= 0 && i < len(calls) {
+args = []verify.Arg{{Name: "key", Got: calls[i].Key, Want: key}}
+}
+verify.Args(tb, "StoreMock.Get", callers, i, args)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "transcript",
+			pkgpath: "example.com/util",
+			style:   "mock",
+			opts:    []Option{WithTranscript()},
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+}
+`,
+			wantW: `type StoreMock struct {
+GetFunc func(key string) (string, error)
+mu sync.Mutex
+getCalls []StoreMockGetCall
+transcript transcript.Log
+}
+
+type StoreMockGetCall struct {
+Key string
+R0 string
+R1 error
+Panic interface{}
+}
+
+func (m *StoreMock) Get(key string) (r0 string, r1 error) {
+call := StoreMockGetCall{Key: key}
+defer func() {
+call.Panic = recover()
+call.R0 = r0
+call.R1 = r1
+m.mu.Lock()
+m.getCalls = append(m.getCalls, call)
+m.transcript.Record("Get", []interface{}{call.Key}, []interface{}{call.R0, call.R1}, call.Panic)
+m.mu.Unlock()
+if call.Panic != nil {
+panic(call.Panic)
+}
+}()
+if m.GetFunc != nil {
+return m.GetFunc(key)
+}
+return "", nil
+}
+
+func (m *StoreMock) GetCalls() []StoreMockGetCall {
+m.mu.Lock()
+defer m.mu.Unlock()
+return append([]StoreMockGetCall(nil), m.getCalls...)
+}
+
+func (m *StoreMock) Transcript() []transcript.Call {
+m.mu.Lock()
+defer m.mu.Unlock()
+return m.transcript.Calls()
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "partial",
+			pkgpath: "example.com/util",
+			style:   "partial",
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+	Delete(string)
+	flush() error
+}
+`,
+			wantW: `type StorePartialMock struct {
+Store
+DeleteFunc func(string)
+GetFunc func(key string) (string, error)
+}
+
+func (m *StorePartialMock) Delete(arg0 string) {
+if m.DeleteFunc != nil {
+m.DeleteFunc(arg0)
+return
+}
+m.Store.Delete(arg0)
+}
+
+func (m *StorePartialMock) Get(key string) (string, error) {
+if m.GetFunc != nil {
+return m.GetFunc(key)
+}
+return m.Store.Get(key)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "partial with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "partial",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerPartialMock struct {
+Doer
+DoFunc func(m int, f string) (r0 bool)
+}
+
+func (m1 *DoerPartialMock) Do(m int, f string) (r0 bool) {
+if m1.DoFunc != nil {
+return m1.DoFunc(m, f)
+}
+return m1.Doer.Do(m, f)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "logging",
+			pkgpath: "example.com/util",
+			style:   "logging",
+			src: `package util
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Close()
+}
+`,
+			wantW: `type StoreLogging struct {
+Delegate Store
+Logger *slog.Logger
+}
+
+func (m *StoreLogging) Close() {
+logger := m.Logger
+if logger == nil {
+logger = slog.Default()
+}
+start := time.Now()
+m.Delegate.Close()
+logger.LogAttrs(context.Background(), slog.LevelInfo, "Store.Close", slog.Duration("elapsed", time.Since(start)))
+}
+
+func (m *StoreLogging) Get(ctx context.Context, key string) (r0 string, r1 error) {
+logger := m.Logger
+if logger == nil {
+logger = slog.Default()
+}
+start := time.Now()
+r0, r1 = m.Delegate.Get(ctx, key)
+level := slog.LevelInfo
+if r1 != nil {
+level = slog.LevelError
+}
+logger.LogAttrs(ctx, level, "Store.Get", slog.Any("key", key), slog.Any("r0", r0), slog.Any("r1", r1), slog.Duration("elapsed", time.Since(start)))
+return r0, r1
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "logging with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "logging",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerLogging struct {
+Delegate Doer
+Logger *slog.Logger
+}
+
+func (m1 *DoerLogging) Do(m int, f string) (r0 bool) {
+logger := m1.Logger
+if logger == nil {
+logger = slog.Default()
+}
+start := time.Now()
+r0 = m1.Delegate.Do(m, f)
+logger.LogAttrs(context.Background(), slog.LevelInfo, "Doer.Do", slog.Any("m", m), slog.Any("f", f), slog.Any("r0", r0), slog.Duration("elapsed", time.Since(start)))
+return r0
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "metrics",
+			pkgpath: "example.com/util",
+			style:   "metrics",
+			src: `package util
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Close()
+}
+`,
+			wantW: `type StoreMetrics struct {
+Delegate Store
+Observe func(method string, elapsed time.Duration, err error)
+}
+
+func (m *StoreMetrics) Close() {
+start := time.Now()
+m.Delegate.Close()
+if m.Observe != nil {
+m.Observe("Close", time.Since(start), nil)
+}
+}
+
+func (m *StoreMetrics) Get(ctx context.Context, key string) (r0 string, r1 error) {
+start := time.Now()
+r0, r1 = m.Delegate.Get(ctx, key)
+if m.Observe != nil {
+m.Observe("Get", time.Since(start), r1)
+}
+return r0, r1
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "metrics with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "metrics",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerMetrics struct {
+Delegate Doer
+Observe func(method string, elapsed time.Duration, err error)
+}
+
+func (m1 *DoerMetrics) Do(m int, f string) (r0 bool) {
+start := time.Now()
+r0 = m1.Delegate.Do(m, f)
+if m1.Observe != nil {
+m1.Observe("Do", time.Since(start), nil)
+}
+return r0
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "middleware",
+			pkgpath: "example.com/util",
+			style:   "middleware",
+			src: `package util
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Close()
+}
+`,
+			wantW: `type StoreMiddleware struct {
+Delegate Store
+Before func(method string, args []interface{})
+After func(method string, results []interface{})
+}
+
+func (m *StoreMiddleware) Close() {
+if m.Before != nil {
+m.Before("Close", []interface{}{})
+}
+m.Delegate.Close()
+if m.After != nil {
+m.After("Close", []interface{}{})
+}
+}
+
+func (m *StoreMiddleware) Get(ctx context.Context, key string) (r0 string, r1 error) {
+if m.Before != nil {
+m.Before("Get", []interface{}{ctx, key})
+}
+r0, r1 = m.Delegate.Get(ctx, key)
+if m.After != nil {
+m.After("Get", []interface{}{r0, r1})
+}
+return r0, r1
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "middleware with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "middleware",
+			src: `package util
+
+type Doer interface {
+	Do(m int, f string) (r0 bool)
+}
+`,
+			wantW: `type DoerMiddleware struct {
+Delegate Doer
+Before func(method string, args []interface{})
+After func(method string, results []interface{})
+}
+
+func (m1 *DoerMiddleware) Do(m int, f string) (r0 bool) {
+if m1.Before != nil {
+m1.Before("Do", []interface{}{m, f})
+}
+r0 = m1.Delegate.Do(m, f)
+if m1.After != nil {
+m1.After("Do", []interface{}{r0})
+}
+return r0
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "nop",
+			pkgpath: "example.com/util",
+			style:   "nop",
+			src: `package util
+
+import "io"
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(string, ...io.Reader)
+}
+`,
+			wantW: `type NopStore struct {
+}
+
+func (NopStore) Get(key string) (string, error) {
+return "", nil
+}
+
+func (NopStore) Put(string, ...io.Reader) {
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "adapter",
+			pkgpath: "example.com/util",
+			style:   "adapter",
+			src: `package util
+
+import "io"
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(string, ...io.Reader)
+}
+
+type Handler interface {
+	Handle(name string, args ...string) error
+}
+`,
+			wantW: `type HandlerFunc func(string, ...string) error
+
+func (f HandlerFunc) Handle(name string, args ...string) error {
+return f(name, args...)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "adapter with params named as the receiver",
+			pkgpath: "example.com/util",
+			style:   "adapter",
+			src: `package util
+
+type Filter interface {
+	Apply(f float64) (f1 float64)
+}
+`,
+			wantW: `type FilterFunc func(float64) float64
+
+func (f2 FilterFunc) Apply(f float64) (f1 float64) {
+return f2(f)
+}
+`,
+			wantErr: false,
+		},
+		{
+			name:    "plugin",
+			pkgpath: "example.com/util",
+			style:   "mock",
+			opts:    []Option{WithPlugin(spanPlugin{})},
+			src: `package util
+
+type Store interface {
+	Get(key string) (string, error)
+}
+`,
+			wantW: `type StoreMock struct {
+GetFunc func(key string) (string, error)
+spans []string
+}
+
+func (m *StoreMock) Get(key string) (r0 string, r1 error) {
+m.spans = append(m.spans, "Get")
+defer func() {
+m.spans = append(m.spans, fmt.Sprint("Get returned", r0, r1))
+}()
+if m.GetFunc != nil {
+return m.GetFunc(key)
+}
+return "", nil
+}
+
+func (m *StoreMock) Spans() []string {
+return m.spans
+}
+`,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exported := packagestest.Export(t, packagestest.Modules, []packagestest.Module{
+				{
+					Name: filepath.Dir(tt.pkgpath),
+					Files: map[string]interface{}{
+						filepath.Join(filepath.Base(tt.pkgpath), "x.go"): tt.src,
+					},
+				},
+			})
+			defer exported.Cleanup()
+			exported.Config.Mode = packages.NeedName | packages.NeedCompiledGoFiles | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+			pkgs, err := packages.Load(exported.Config, tt.pkgpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pkgs) != 1 {
+				t.Fatal("load package error")
+			}
+			pkg := pkgs[0]
+			for _, f := range pkg.Syntax {
+				err := walk(f, pkg.TypesInfo, func(name string, typ types.Type, err error) error {
+					if err != nil {
+						t.Fatal(err)
+					}
+					style := tt.style
+					if style == "" {
+						style = "mock"
+					}
+					mock, err := styles[style](types.NewTypeName(token.NoPos, nil, name, nil), typ, tt.opts...)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if mock == nil {
+						return nil
 					}
 
 					w := &bytes.Buffer{}
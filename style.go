@@ -0,0 +1,138 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+type generator interface {
+	WriteTo(w io.Writer) error
+}
+
+// importer is implemented by generators which refer to packages goimports can not resolve.
+type importer interface {
+	Imports() []string
+}
+
+// styleFunc returns a generator for the named type of obj, typ is *types.Interface or *types.Signature.
+// obj is qualified by its package in the generated code, which is nil if the code is generated
+// into the package of the type. A nil generator is returned if the style does not support the type.
+// opts are the features which are enabled by -features, styles other than SimpleMock ignore them.
+type styleFunc func(obj *types.TypeName, typ types.Type, opts ...Option) (generator, error)
+
+// interfaceStyle returns a styleFunc which only supports interfaces.
+func interfaceStyle(f func(iface *types.TypeName, ifaceType *types.Interface, opts ...Option) (generator, error)) styleFunc {
+	return func(obj *types.TypeName, typ types.Type, opts ...Option) (generator, error) {
+		ifaceType, ok := typ.(*types.Interface)
+		if !ok {
+			return nil, nil
+		}
+		return f(obj, ifaceType, opts...)
+	}
+}
+
+// styles are the kinds of code which can be generated from a type.
+var styles = map[string]styleFunc{
+	"mock": func(obj *types.TypeName, typ types.Type, opts ...Option) (generator, error) {
+		switch typ := typ.(type) {
+		case *types.Interface:
+			return NewSimpleMock(obj.Name()+"Mock", typ, opts...)
+		case *types.Signature:
+			return NewFuncMock(obj.Name()+"Mock", obj, typ, opts...)
+		}
+		return nil, nil
+	},
+	"spy": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, opts ...Option) (generator, error) {
+		return NewSimpleMock(iface.Name()+"Spy", ifaceType, append([]Option{WithDelegate(iface), WithCallLog()}, opts...)...)
+	}),
+	"adapter": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		if ifaceType.NumMethods() != 1 {
+			return nil, nil
+		}
+		return NewAdapter(iface.Name()+"Func", ifaceType)
+	}),
+	"fault": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewFaultMock(iface.Name()+"Fault", iface, ifaceType)
+	}),
+	"logging": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewLoggingDecorator(iface.Name()+"Logging", iface, ifaceType)
+	}),
+	"metrics": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewMetricsDecorator(iface.Name()+"Metrics", iface, ifaceType)
+	}),
+	"middleware": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewMiddlewareDecorator(iface.Name()+"Middleware", iface, ifaceType)
+	}),
+	"nop": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewNop("Nop"+exportName(iface.Name()), ifaceType)
+	}),
+	"partial": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewPartialMock(iface.Name()+"PartialMock", iface, ifaceType)
+	}),
+	"replay": interfaceStyle(func(iface *types.TypeName, ifaceType *types.Interface, _ ...Option) (generator, error) {
+		return NewReplayMock(iface.Name()+"Replay", iface, ifaceType)
+	}),
+}
+
+// features are optional features of mocks.
+var features = map[string]Option{
+	"assert":     WithAssert(),
+	"context":    WithContext(),
+	"hooks":      WithHooks(),
+	"options":    WithConstructor(),
+	"reset":      WithReset(),
+	"setters":    WithSetters(),
+	"transcript": WithTranscript(),
+}
+
+// parseFeatures parses a comma separated list of feature names.
+func parseFeatures(s string) ([]Option, error) {
+	var opts []Option
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		opt, ok := features[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown feature %q, available features are %s", name, strings.Join(featureNames(), ", "))
+		}
+		opts = append(opts, opt)
+	}
+	return opts, nil
+}
+
+func featureNames() []string {
+	var names []string
+	for name := range features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseStyles parses a comma separated list of style names.
+func parseStyles(s string) ([]styleFunc, error) {
+	var fns []styleFunc
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		fn, ok := styles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown style %q, available styles are %s", name, strings.Join(styleNames(), ", "))
+		}
+		fns = append(fns, fn)
+	}
+	return fns, nil
+}
+
+func styleNames() []string {
+	var names []string
+	for name := range styles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,367 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// syntaxResolver resolves types declared in a package which does not type-check
+// from the syntax and import declarations alone, without type information.
+// Type expressions are resolved textually: a type of an imported package is named
+// by the name of the import in the source file, and an unknown type is named as written.
+type syntaxResolver struct {
+	fset *token.FileSet
+	// pkg is the package of local types, nil if they are generated into the same package.
+	pkg     *types.Package
+	specs   map[string]*ast.TypeSpec
+	files   map[*ast.TypeSpec]*ast.File
+	imports map[*ast.File]map[string]*types.Package
+	named   map[string]*types.Named
+	// aliases are aliases being resolved, which are not resolved again for cycles.
+	aliases map[string]bool
+	// info is used for embedded interfaces of imported packages if it is not nil,
+	// since their methods can not be resolved from syntax.
+	info typeInfo
+}
+
+// newSyntaxResolver returns a syntaxResolver of the package of files.
+// pkg is nil if the code is generated into the same package, and info may be nil.
+func newSyntaxResolver(fset *token.FileSet, pkg *types.Package, files []*ast.File, info typeInfo) *syntaxResolver {
+	r := &syntaxResolver{
+		fset:    fset,
+		pkg:     pkg,
+		info:    info,
+		specs:   make(map[string]*ast.TypeSpec),
+		files:   make(map[*ast.TypeSpec]*ast.File),
+		imports: make(map[*ast.File]map[string]*types.Package),
+		named:   make(map[string]*types.Named),
+		aliases: make(map[string]bool),
+	}
+	for _, file := range files {
+		imports := make(map[string]*types.Package)
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				continue
+			}
+			name := importName(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = types.NewPackage(path, name)
+		}
+		r.imports[file] = imports
+		for _, spec := range typeSpecs(file) {
+			r.specs[spec.Name.Name] = spec
+			r.files[spec] = file
+		}
+	}
+	return r
+}
+
+// importName returns the package name assumed from the import path,
+// which is the last element without the major version suffix.
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if strings.HasPrefix(name, "v") {
+		if _, err := strconv.Atoi(name[1:]); err == nil && path.Dir(importPath) != "." {
+			name = path.Base(path.Dir(importPath))
+		}
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexAny(name, ".-"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// typeSpecs returns type declarations at the top level of file.
+func typeSpecs(file *ast.File) []*ast.TypeSpec {
+	var specs []*ast.TypeSpec
+	for _, decl := range file.Decls {
+		decl, ok := decl.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range decl.Specs {
+			specs = append(specs, spec.(*ast.TypeSpec))
+		}
+	}
+	return specs
+}
+
+// names returns names of exported interface and func types declared in file.
+func (r *syntaxResolver) names(file *ast.File) []string {
+	var names []string
+	for _, spec := range typeSpecs(file) {
+		if !spec.Name.IsExported() {
+			continue
+		}
+		switch spec.Type.(type) {
+		case *ast.InterfaceType, *ast.FuncType:
+			names = append(names, spec.Name.Name)
+		}
+	}
+	return names
+}
+
+// position returns the position of the declared type name.
+func (r *syntaxResolver) position(name string) token.Position {
+	if spec, ok := r.specs[name]; ok {
+		return r.fset.Position(spec.Name.Pos())
+	}
+	return token.Position{}
+}
+
+// typeOf returns *types.Interface or *types.Signature of the declared type name,
+// and the file which declares it.
+func (r *syntaxResolver) typeOf(name string) (types.Type, *ast.File, error) {
+	spec, ok := r.specs[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is not declared", name)
+	}
+	if spec.TypeParams != nil {
+		return nil, nil, fmt.Errorf("%s has type parameters which can not be resolved from syntax", name)
+	}
+	file := r.files[spec]
+	switch spec.Type.(type) {
+	case *ast.InterfaceType, *ast.FuncType:
+	default:
+		return nil, nil, fmt.Errorf("%s is not an interface or func type", name)
+	}
+	typ, err := r.resolve(file, spec.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+	return typ, file, nil
+}
+
+// resolve returns the type of expr written in file.
+func (r *syntaxResolver) resolve(file *ast.File, expr ast.Expr) (types.Type, error) {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return r.resolve(file, expr.X)
+	case *ast.Ident:
+		return r.resolveIdent(expr.Name), nil
+	case *ast.SelectorExpr:
+		x, ok := expr.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("invalid type %s", types.ExprString(expr))
+		}
+		pkg, ok := r.imports[file][x.Name]
+		if !ok {
+			// the package name is written as is, and goimports may resolve it
+			pkg = types.NewPackage(x.Name, x.Name)
+		}
+		return types.NewNamed(types.NewTypeName(expr.Pos(), pkg, expr.Sel.Name, nil), types.Typ[types.Invalid], nil), nil
+	case *ast.StarExpr:
+		elem, err := r.resolve(file, expr.X)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewPointer(elem), nil
+	case *ast.ArrayType:
+		elem, err := r.resolve(file, expr.Elt)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Len == nil {
+			return types.NewSlice(elem), nil
+		}
+		lit, ok := expr.Len.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("length of array %s can not be resolved from syntax", types.ExprString(expr))
+		}
+		n, ok := constant.Int64Val(constant.MakeFromLiteral(lit.Value, lit.Kind, 0))
+		if !ok {
+			return nil, fmt.Errorf("invalid length of array %s", types.ExprString(expr))
+		}
+		return types.NewArray(elem, n), nil
+	case *ast.MapType:
+		key, err := r.resolve(file, expr.Key)
+		if err != nil {
+			return nil, err
+		}
+		elem, err := r.resolve(file, expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewMap(key, elem), nil
+	case *ast.ChanType:
+		elem, err := r.resolve(file, expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		dir := types.SendRecv
+		switch expr.Dir {
+		case ast.SEND:
+			dir = types.SendOnly
+		case ast.RECV:
+			dir = types.RecvOnly
+		}
+		return types.NewChan(dir, elem), nil
+	case *ast.FuncType:
+		return r.resolveSignature(file, expr)
+	case *ast.InterfaceType:
+		return r.resolveInterface(file, expr)
+	case *ast.StructType:
+		var fields []*types.Var
+		var tags []string
+		for _, field := range expr.Fields.List {
+			typ, err := r.resolve(file, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			tag := ""
+			if field.Tag != nil {
+				tag, _ = strconv.Unquote(field.Tag.Value)
+			}
+			if len(field.Names) == 0 {
+				fields = append(fields, types.NewField(field.Pos(), r.pkg, embeddedName(typ), typ, true))
+				tags = append(tags, tag)
+			}
+			for _, name := range field.Names {
+				fields = append(fields, types.NewField(name.Pos(), r.pkg, name.Name, typ, false))
+				tags = append(tags, tag)
+			}
+		}
+		return types.NewStruct(fields, tags), nil
+	}
+	return nil, fmt.Errorf("type %s can not be resolved from syntax", types.ExprString(expr))
+}
+
+// resolveIdent returns a predeclared type or a type declared in the package.
+// A type which is not declared is named as written, whose underlying type is unknown.
+func (r *syntaxResolver) resolveIdent(name string) types.Type {
+	if obj, ok := types.Universe.Lookup(name).(*types.TypeName); ok {
+		return obj.Type()
+	}
+	if named, ok := r.named[name]; ok {
+		return named
+	}
+	spec, ok := r.specs[name]
+	if ok && spec.Assign.IsValid() && spec.TypeParams == nil && !r.aliases[name] {
+		r.aliases[name] = true
+		typ, err := r.resolve(r.files[spec], spec.Type)
+		delete(r.aliases, name)
+		if err == nil {
+			return typ
+		}
+	}
+	named := types.NewNamed(types.NewTypeName(token.NoPos, r.pkg, name, nil), types.Typ[types.Invalid], nil)
+	r.named[name] = named
+	if ok && spec.TypeParams == nil {
+		if typ, err := r.resolve(r.files[spec], spec.Type); err == nil {
+			named.SetUnderlying(typ.Underlying())
+		}
+	}
+	return named
+}
+
+func (r *syntaxResolver) resolveSignature(file *ast.File, expr *ast.FuncType) (*types.Signature, error) {
+	if expr.TypeParams != nil {
+		return nil, fmt.Errorf("type parameters of %s can not be resolved from syntax", types.ExprString(expr))
+	}
+	params, variadic, err := r.resolveFields(file, expr.Params)
+	if err != nil {
+		return nil, err
+	}
+	results, _, err := r.resolveFields(file, expr.Results)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewSignatureType(nil, nil, nil, params, results, variadic), nil
+}
+
+// resolveFields returns params or results of fields, and reports whether the last param is variadic.
+func (r *syntaxResolver) resolveFields(file *ast.File, fields *ast.FieldList) (*types.Tuple, bool, error) {
+	if fields == nil {
+		return nil, false, nil
+	}
+	var vars []*types.Var
+	variadic := false
+	for _, field := range fields.List {
+		expr := field.Type
+		if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+			expr = &ast.ArrayType{Lbrack: ellipsis.Pos(), Elt: ellipsis.Elt}
+			variadic = true
+		}
+		typ, err := r.resolve(file, expr)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(field.Names) == 0 {
+			vars = append(vars, types.NewParam(field.Pos(), r.pkg, "", typ))
+		}
+		for _, name := range field.Names {
+			vars = append(vars, types.NewParam(name.Pos(), r.pkg, name.Name, typ))
+		}
+	}
+	return types.NewTuple(vars...), variadic, nil
+}
+
+func (r *syntaxResolver) resolveInterface(file *ast.File, expr *ast.InterfaceType) (*types.Interface, error) {
+	var methods []*types.Func
+	var embeddeds []types.Type
+	for _, field := range expr.Methods.List {
+		if len(field.Names) == 0 {
+			typ, err := r.resolve(file, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := field.Type.(*ast.SelectorExpr); ok && r.info != nil {
+				if t := r.info.TypeOf(field.Type); t != nil && !hasInvalidType(t.Underlying()) {
+					typ = t
+				}
+			}
+			if _, ok := typ.Underlying().(*types.Interface); !ok {
+				return nil, fmt.Errorf("embedded interface %s can not be resolved from syntax", types.ExprString(field.Type))
+			}
+			embeddeds = append(embeddeds, typ)
+			continue
+		}
+		sig, err := r.resolveSignature(file, field.Type.(*ast.FuncType))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range field.Names {
+			methods = append(methods, types.NewFunc(name.Pos(), r.pkg, name.Name, sig))
+		}
+	}
+	return types.NewInterfaceType(methods, embeddeds).Complete(), nil
+}
+
+// embeddedName returns the field name of the embedded type.
+func embeddedName(typ types.Type) string {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if named, ok := typ.(*types.Named); ok {
+		return named.Obj().Name()
+	}
+	return types.TypeString(typ, nil)
+}
+
+// addImports adds imports of file to im with their names in the file,
+// since types resolved from syntax are qualified by them.
+func addImports(im *Import, file *ast.File) {
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name == nil {
+			im.Add(path)
+			continue
+		}
+		if name := spec.Name.Name; name != "_" && name != "." {
+			im.AddNamed(name, path)
+		}
+	}
+}
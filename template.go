@@ -0,0 +1,93 @@
+package simplemock
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data passed to a template of -template.
+type TemplateData struct {
+	// PackageName is the package name of the generated file.
+	PackageName string
+	// Types are models of the discovered types.
+	Types []*Model
+}
+
+// templateFuncs are helper funcs of templates.
+var templateFuncs = template.FuncMap{
+	// zeroValue returns the zero value of the type of the param.
+	"zeroValue": func(p Param) string {
+		return TypeZeroValue(p.typ)
+	},
+	// typeString returns the type of the param, which is prefixed with ... if it is variadic.
+	"typeString": func(p Param) string {
+		if slice, ok := p.typ.(*types.Slice); ok && p.Variadic {
+			return "..." + TypeString(slice.Elem())
+		}
+		return p.Type
+	},
+	// paramList returns a comma separated list of names and types of params,
+	// unnamed params are named argN.
+	"paramList": func(params []Param) string {
+		var list []string
+		for i, p := range params {
+			name := p.Name
+			if name == "" || name == "_" {
+				name = "arg" + strconv.Itoa(i)
+			}
+			typ := p.Type
+			if slice, ok := p.typ.(*types.Slice); ok && p.Variadic {
+				typ = "..." + TypeString(slice.Elem())
+			}
+			list = append(list, name+" "+typ)
+		}
+		return strings.Join(list, ", ")
+	},
+}
+
+// generateTemplate renders the template of opts.Template with models of the loaded types of each package.
+// The output is formatted if it is Go source code.
+func generateTemplate(ctx context.Context, opts Options) ([]GeneratedFile, error) {
+	if opts.Syntax {
+		return nil, errors.New("templates can not be generated from syntax only")
+	}
+	tmpl, err := template.New(filepath.Base(opts.Template)).Funcs(templateFuncs).ParseFiles(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return generatePackages(ctx, opts, func(ctx context.Context, pkg *loadedPackage, path string) (GeneratedFile, error) {
+		models, diags, err := packageModels(ctx, opts, pkg)
+		if err != nil {
+			return GeneratedFile{}, err
+		}
+		data := TemplateData{PackageName: opts.PackageName, Types: models}
+		if len(data.PackageName) == 0 {
+			data.PackageName = pkg.Name
+		}
+
+		buf := bytes.NewBuffer(nil)
+		if err := tmpl.Execute(buf, data); err != nil {
+			return GeneratedFile{}, fmt.Errorf("execute template: %w", err)
+		}
+		file := GeneratedFile{Path: path, Package: data.PackageName, Diagnostics: diags}
+		if _, err := parser.ParseFile(token.NewFileSet(), "", buf.Bytes(), parser.PackageClauseOnly); err == nil {
+			gofile := NewGoFile()
+			gofile.Buffer = buf
+			if err := gofile.Format(); err != nil {
+				file.Diagnostics = append(file.Diagnostics, Diagnostic{Message: fmt.Sprintf("format source code: %v", err)})
+			}
+			buf = gofile.Buffer
+		}
+		file.Source = buf.Bytes()
+		return file, nil
+	})
+}
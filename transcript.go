@@ -0,0 +1,67 @@
+package simplemock
+
+import (
+	"fmt"
+	"go/types"
+	"io"
+	"strings"
+)
+
+const transcriptPkgPath = "github.com/theoden9014/simplemock/transcript"
+
+// WithTranscript records every call into a transcript, and generates Transcript
+// which returns the calls to be dumped as JSON or compared with a golden file. It implies WithCallLog.
+func WithTranscript() Option {
+	return func(m *SimpleMock) {
+		m.callLog = true
+		m.transcript = true
+	}
+}
+
+func (m *SimpleMock) addTranscript() error {
+	logType := NewNamedType(transcriptPkgPath, "Log", types.NewStruct(nil, nil))
+	if err := m.structGenerator.AddField(NewField("transcript", logType)); err != nil {
+		return err
+	}
+
+	callsType := types.NewSlice(NewNamedType(transcriptPkgPath, "Call", types.NewStruct(nil, nil)))
+	accessor := NewFunc(m.helperName(`Transcript`), FieldList{}, FieldList{NewField("", callsType)}, m.structGenerator, "m", false)
+	accessor.SetBlockWriter(func(fn *Func, w io.Writer) error {
+		recvName := fn.RecvName()
+		fmt.Fprintln(w, recvName+`.mu.Lock()`)
+		fmt.Fprintln(w, `defer `+recvName+`.mu.Unlock()`)
+		fmt.Fprintln(w, `return `+recvName+`.transcript.Calls()`)
+		return nil
+	})
+	m.funcGenerators = append(m.funcGenerators, accessor)
+	return nil
+}
+
+// writeTranscriptRecord writes a statement which records the call into the transcript,
+// it must be written while the lock is held.
+func writeTranscriptRecord(w io.Writer, recvName, callVar string, method *mockMethod) {
+	fields := method.call.FieldList()
+	var args, results []string
+	for i := 0; i < method.params.Len(); i++ {
+		args = append(args, callVar+`.`+fields.At(i).Name())
+	}
+	for i := 0; i < method.results.Len(); i++ {
+		results = append(results, callVar+`.`+fields.At(method.params.Len()+i).Name())
+	}
+	fmt.Fprintln(w, recvName+`.transcript.Record("`+method.name+`", []interface{}{`+strings.Join(args, `, `)+`}, []interface{}{`+strings.Join(results, `, `)+`}, `+callVar+`.Panic)`)
+}
+
+// Imports returns import paths of runtime packages used by the generated code.
+func (m *SimpleMock) Imports() []string {
+	var imports []string
+	if m.assert {
+		imports = append(imports, verifyPkgPath)
+	}
+	if m.transcript {
+		imports = append(imports, transcriptPkgPath)
+	}
+	for _, p := range m.plugins {
+		imports = append(imports, p.Imports()...)
+	}
+	return imports
+}
@@ -0,0 +1,152 @@
+// Package transcript records calls to a mock as JSON, and compares them with a golden file,
+// which is used by mocks generated with -features transcript.
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Update makes Golden write golden files instead of comparing with them.
+// This package does not register a flag, tests set it from their own flag:
+//
+//	flag.BoolVar(&transcript.Update, "update", false, "update golden files")
+var Update bool
+
+// Call is a recorded call.
+type Call struct {
+	Seq     int               `json:"seq"`
+	Method  string            `json:"method"`
+	Args    []json.RawMessage `json:"args"`
+	Results []json.RawMessage `json:"results"`
+	Panic   string            `json:"panic,omitempty"`
+	Time    time.Time         `json:"time"`
+	// Goroutine is the id of the goroutine which made the call, or 0 if it is unknown.
+	Goroutine int64 `json:"goroutine,omitempty"`
+}
+
+// Log is a transcript of calls. The zero value is ready to use.
+// It is not safe for concurrent use, mocks guard it with their lock.
+type Log struct {
+	calls []Call
+}
+
+// Record appends a call of the method with args and results, and a recovered panic if it is not nil.
+func (l *Log) Record(method string, args, results []interface{}, panicValue interface{}) {
+	call := Call{
+		Seq:       len(l.calls),
+		Method:    method,
+		Args:      encode(args),
+		Results:   encode(results),
+		Time:      time.Now(),
+		Goroutine: goroutineID(),
+	}
+	if panicValue != nil {
+		call.Panic = fmt.Sprint(panicValue)
+	}
+	l.calls = append(l.calls, call)
+}
+
+// Calls returns a copy of recorded calls.
+func (l *Log) Calls() []Call {
+	return append([]Call(nil), l.calls...)
+}
+
+// encode encodes values as JSON, errors are encoded as their messages,
+// and values which can not be encoded are encoded as their types.
+func encode(values []interface{}) []json.RawMessage {
+	raws := make([]json.RawMessage, 0, len(values))
+	for _, v := range values {
+		if err, ok := v.(error); ok {
+			v = err.Error()
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprintf("%T", v))
+		}
+		raws = append(raws, raw)
+	}
+	return raws
+}
+
+// goroutineID parses the id of the current goroutine from its stack trace.
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, err := strconv.ParseInt(string(buf), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+// Marshal returns calls as indented JSON.
+func Marshal(calls []Call) ([]byte, error) {
+	if calls == nil {
+		calls = []Call{}
+	}
+	b, err := json.MarshalIndent(calls, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// Golden compares calls with the golden file at path, and reports a failure if they differ.
+// Time and Goroutine are not compared since they vary between runs.
+// The golden file is written instead if Update is set.
+func Golden(tb testing.TB, path string, calls []Call) {
+	tb.Helper()
+	got, err := marshalGolden(calls)
+	if err != nil {
+		tb.Fatalf("marshal transcript: %v", err)
+	}
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("update golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatalf("update golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("read golden file: %v (set Update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		tb.Errorf("transcript differs from %s (set Update to update it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// marshalGolden returns calls as indented JSON without Time and Goroutine.
+func marshalGolden(calls []Call) ([]byte, error) {
+	type goldenCall struct {
+		Seq     int               `json:"seq"`
+		Method  string            `json:"method"`
+		Args    []json.RawMessage `json:"args"`
+		Results []json.RawMessage `json:"results"`
+		Panic   string            `json:"panic,omitempty"`
+	}
+	golden := make([]goldenCall, len(calls))
+	for i, call := range calls {
+		golden[i] = goldenCall{Seq: call.Seq, Method: call.Method, Args: call.Args, Results: call.Results, Panic: call.Panic}
+	}
+	b, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,97 @@
+package transcript
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLog_Record(t *testing.T) {
+	var l Log
+	l.Record("Get", []interface{}{"k", func() {}}, []interface{}{0, errors.New("not found")}, nil)
+	l.Record("Close", []interface{}{}, []interface{}{}, "boom")
+
+	calls := l.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("len(Calls()) = %d, want 2", len(calls))
+	}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "arg", got: string(calls[0].Args[0]), want: `"k"`},
+		{name: "unencodable arg", got: string(calls[0].Args[1]), want: `"func()"`},
+		{name: "error result", got: string(calls[0].Results[1]), want: `"not found"`},
+		{name: "panic", got: calls[1].Panic, want: "boom"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+	if calls[1].Seq != 1 {
+		t.Errorf("Seq = %d, want 1", calls[1].Seq)
+	}
+	if calls[0].Goroutine == 0 {
+		t.Error("Goroutine = 0, want the id of the goroutine")
+	}
+	if calls[0].Time.IsZero() {
+		t.Error("Time is zero")
+	}
+}
+
+func TestGolden(t *testing.T) {
+	var l Log
+	l.Record("Get", []interface{}{"k"}, []interface{}{1, nil}, nil)
+
+	path := filepath.Join(t.TempDir(), "transcript.json")
+	want := `[
+  {
+    "seq": 0,
+    "method": "Get",
+    "args": [
+      "k"
+    ],
+    "results": [
+      1,
+      null
+    ]
+  }
+]
+`
+	if err := os.WriteFile(path, []byte(want), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	Golden(t, path, l.Calls())
+}
+
+func TestGolden_Update(t *testing.T) {
+	Update = true
+	defer func() { Update = false }()
+
+	var l Log
+	l.Record("Close", nil, []interface{}{nil}, nil)
+	path := filepath.Join(t.TempDir(), "testdata", "transcript.json")
+	Golden(t, path, l.Calls())
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "seq": 0,
+    "method": "Close",
+    "args": [],
+    "results": [
+      null
+    ]
+  }
+]
+`
+	if string(got) != want {
+		t.Errorf("golden file = %s, want %s", got, want)
+	}
+}
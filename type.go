@@ -1,6 +1,7 @@
 package simplemock
 
 import (
+	"go/token"
 	"go/types"
 	"path/filepath"
 )
@@ -9,6 +10,10 @@ import (
 func TypeZeroValue(t types.Type) string {
 	switch v := t.Underlying().(type) {
 	case *types.Basic:
+		if v.Kind() == types.Invalid {
+			// the underlying type of a type resolved from syntax may be unknown
+			return `*new(` + TypeString(t) + `)`
+		}
 		return typeBasicZeroValue(v)
 	case *types.Struct:
 		return types.TypeString(t, qualifier) + `{}`
@@ -40,7 +45,7 @@ func qualifier(pkg *types.Package) string {
 	if pkg.Path() == "" {
 		return ""
 	}
-	return filepath.Base(pkg.Path())
+	return pkg.Name()
 }
 
 // TypeString convert types.Type to string
@@ -58,3 +63,180 @@ func TypeString(t types.Type) string {
 		return types.TypeString(t, qualifier)
 	}
 }
+
+// NewNamedType returns a named type declared in the package of pkgPath,
+// an empty pkgPath means the package of the generated code.
+func NewNamedType(pkgPath, name string, underlying types.Type) *types.Named {
+	var pkg *types.Package
+	if pkgPath != "" {
+		pkg = types.NewPackage(pkgPath, filepath.Base(pkgPath))
+	}
+	return types.NewNamed(types.NewTypeName(token.NoPos, pkg, name, nil), underlying, nil)
+}
+
+// localizeType returns typ whose named types declared in pkg are replaced by unqualified ones,
+// for the code generated into pkg.
+func localizeType(typ types.Type, pkg *types.Package) types.Type {
+	named := make(map[*types.Named]*types.Named)
+	var localize func(t types.Type) types.Type
+	localizeTuple := func(t *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, t.Len())
+		for i := range vars {
+			v := t.At(i)
+			vars[i] = types.NewVar(v.Pos(), v.Pkg(), v.Name(), localize(v.Type()))
+		}
+		return types.NewTuple(vars...)
+	}
+	localizeSignature := func(t *types.Signature) *types.Signature {
+		return types.NewSignatureType(nil, nil, nil, localizeTuple(t.Params()), localizeTuple(t.Results()), t.Variadic())
+	}
+	localize = func(t types.Type) types.Type {
+		switch t := t.(type) {
+		case *types.Named:
+			obj := t.Obj()
+			if obj.Pkg() != pkg || t.TypeArgs() != nil {
+				return t
+			}
+			if n, ok := named[t]; ok {
+				return n
+			}
+			n := types.NewNamed(types.NewTypeName(obj.Pos(), nil, obj.Name(), nil), t.Underlying(), nil)
+			named[t] = n
+			return n
+		case *types.Pointer:
+			return types.NewPointer(localize(t.Elem()))
+		case *types.Slice:
+			return types.NewSlice(localize(t.Elem()))
+		case *types.Array:
+			return types.NewArray(localize(t.Elem()), t.Len())
+		case *types.Map:
+			return types.NewMap(localize(t.Key()), localize(t.Elem()))
+		case *types.Chan:
+			return types.NewChan(t.Dir(), localize(t.Elem()))
+		case *types.Signature:
+			return localizeSignature(t)
+		case *types.Struct:
+			fields := make([]*types.Var, t.NumFields())
+			tags := make([]string, t.NumFields())
+			for i := range fields {
+				f := t.Field(i)
+				fields[i] = types.NewField(f.Pos(), f.Pkg(), f.Name(), localize(f.Type()), f.Embedded())
+				tags[i] = t.Tag(i)
+			}
+			return types.NewStruct(fields, tags)
+		case *types.Interface:
+			methods := make([]*types.Func, t.NumMethods())
+			for i := range methods {
+				m := t.Method(i)
+				methods[i] = types.NewFunc(m.Pos(), m.Pkg(), m.Name(), localizeSignature(m.Type().(*types.Signature)))
+			}
+			return types.NewInterfaceType(methods, nil).Complete()
+		}
+		return t
+	}
+	return localize(typ)
+}
+
+// namedType returns a named type of obj with the underlying type, which is qualified by
+// the package of obj in the generated code, and unqualified if it is nil.
+func namedType(obj *types.TypeName, underlying types.Type) *types.Named {
+	return types.NewNamed(types.NewTypeName(obj.Pos(), obj.Pkg(), obj.Name(), nil), underlying, nil)
+}
+
+// ExtractInterface returns an interface of the exported method set of *named.
+func ExtractInterface(named *types.Named) *types.Interface {
+	var methods []*types.Func
+	mset := types.NewMethodSet(types.NewPointer(named))
+	for i := 0; i < mset.Len(); i++ {
+		method, ok := mset.At(i).Obj().(*types.Func)
+		if !ok || !method.Exported() {
+			continue
+		}
+		sig := method.Type().(*types.Signature)
+		sig = types.NewSignatureType(nil, nil, nil, sig.Params(), sig.Results(), sig.Variadic())
+		methods = append(methods, types.NewFunc(method.Pos(), method.Pkg(), method.Name(), sig))
+	}
+	return types.NewInterfaceType(methods, nil).Complete()
+}
+
+// unnamedSignature returns sig without names of params and results.
+func unnamedSignature(sig *types.Signature) *types.Signature {
+	unnamed := func(t *types.Tuple) *types.Tuple {
+		vars := make([]*types.Var, t.Len())
+		for i := range vars {
+			vars[i] = types.NewVar(token.NoPos, nil, "", t.At(i).Type())
+		}
+		return types.NewTuple(vars...)
+	}
+	return types.NewSignatureType(nil, nil, nil, unnamed(sig.Params()), unnamed(sig.Results()), sig.Variadic())
+}
+
+// IsContext reports whether t is context.Context.
+func IsContext(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// IsError reports whether t is the predeclared error type.
+func IsError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// visitTypes calls f with typ and types which compose it, but not underlying types of named types.
+func visitTypes(typ types.Type, f func(t types.Type)) {
+	var visit func(t types.Type)
+	visitTuple := func(t *types.Tuple) {
+		for i := 0; i < t.Len(); i++ {
+			visit(t.At(i).Type())
+		}
+	}
+	visit = func(t types.Type) {
+		f(t)
+		switch t := t.(type) {
+		case *types.Named:
+			if args := t.TypeArgs(); args != nil {
+				for i := 0; i < args.Len(); i++ {
+					visit(args.At(i))
+				}
+			}
+		case *types.Pointer:
+			visit(t.Elem())
+		case *types.Slice:
+			visit(t.Elem())
+		case *types.Array:
+			visit(t.Elem())
+		case *types.Map:
+			visit(t.Key())
+			visit(t.Elem())
+		case *types.Chan:
+			visit(t.Elem())
+		case *types.Signature:
+			visitTuple(t.Params())
+			visitTuple(t.Results())
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				visit(t.Field(i).Type())
+			}
+		case *types.Interface:
+			for i := 0; i < t.NumMethods(); i++ {
+				visit(t.Method(i).Type())
+			}
+		}
+	}
+	visit(typ)
+}
+
+// hasInvalidType reports whether typ refers to an invalid type, which is a type with errors.
+func hasInvalidType(typ types.Type) bool {
+	invalid := false
+	visitTypes(typ, func(t types.Type) {
+		if basic, ok := t.(*types.Basic); ok && basic.Kind() == types.Invalid {
+			invalid = true
+		}
+	})
+	return invalid
+}
@@ -122,3 +122,32 @@ func parseGoCode(tb testing.TB, fset *token.FileSet, src io.Reader) (*ast.File,
 
 	return f, info, nil
 }
+
+func TestExtractInterface(t *testing.T) {
+	src := `package main
+
+type Client struct{}
+
+func (c *Client) Get(key string) (string, error) { return "", nil }
+func (c Client) Keys(prefix string, opts ...int) []string { return nil }
+func (c *Client) reset() {}
+`
+	f, info, err := parseGoCode(t, token.NewFileSet(), bytes.NewBufferString(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	named := info.TypeOf(f.Scope.Lookup("Client").Decl.(*ast.TypeSpec).Name).(*types.Named)
+
+	w := &bytes.Buffer{}
+	if err := simplemock.NewInterface("Client", simplemock.ExtractInterface(named)).WriteTo(w); err != nil {
+		t.Fatal(err)
+	}
+	want := `type Client interface {
+Get(key string) (string, error)
+Keys(prefix string, opts ...int) []string
+}
+`
+	if got := w.String(); got != want {
+		t.Errorf("WriteTo() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+// Package verify reports failures of assertions on recorded calls,
+// which is used by mocks generated with -features assert.
+package verify
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// Caller returns file:line of the caller, skip is the number of stack frames to skip
+// as runtime.Caller, 0 identifies the caller of Caller.
+func Caller(skip int) string {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// Arg is an argument of a recorded call and the expected value.
+type Arg struct {
+	Name string
+	Got  interface{}
+	Want interface{}
+}
+
+// Count reports a failure if the method is not called n times.
+// callers are the call sites of the recorded calls.
+func Count(tb testing.TB, method string, callers []string, n int) {
+	tb.Helper()
+	if len(callers) == n {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s was called %d times, want %d", method, len(callers), n)
+	for i, caller := range callers {
+		fmt.Fprintf(&b, "\n\tcall #%d at %s", i, caller)
+	}
+	tb.Error(b.String())
+}
+
+// Args reports a failure for each argument of the i-th call of the method which differs
+// from the expected value. callers are the call sites of the recorded calls,
+// and args are the arguments of the i-th call.
+func Args(tb testing.TB, method string, callers []string, i int, args []Arg) {
+	tb.Helper()
+	if i < 0 || i >= len(callers) {
+		tb.Errorf("%s was called %d times, want call #%d", method, len(callers), i)
+		return
+	}
+	for _, arg := range args {
+		if diff := Diff(arg.Want, arg.Got); diff != "" {
+			tb.Errorf("%s call #%d at %s: argument %s mismatch (-want +got):\n%s", method, i, callers[i], arg.Name, diff)
+		}
+	}
+}
+
+// Diff returns a human-readable report of the differences between want and got,
+// which compares unexported fields too. An empty string is returned if they are equal.
+func Diff(want, got interface{}) string {
+	return cmp.Diff(want, got, cmp.Exporter(func(reflect.Type) bool { return true }))
+}
@@ -0,0 +1,112 @@
+package verify
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type user struct {
+	Name string
+	age  int
+}
+
+func TestCaller(t *testing.T) {
+	if got := Caller(0); !strings.HasPrefix(got, "verify_test.go:") {
+		t.Errorf("Caller() = %q, want verify_test.go:<line>", got)
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		callers []string
+		n       int
+		want    []string
+	}{
+		{
+			name:    "match",
+			callers: []string{"a_test.go:1"},
+			n:       1,
+		},
+		{
+			name:    "mismatch",
+			callers: []string{"a_test.go:1", "a_test.go:2"},
+			n:       1,
+			want:    []string{"M.Get was called 2 times, want 1\n\tcall #0 at a_test.go:1\n\tcall #1 at a_test.go:2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			Count(r, "M.Get", tt.callers, tt.n)
+			if d := Diff(tt.want, r.errors); d != "" {
+				t.Errorf("Count() errors mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}
+
+func TestArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		callers []string
+		i       int
+		args    []Arg
+		want    []string
+		wantSub string
+	}{
+		{
+			name:    "match",
+			callers: []string{"a_test.go:1"},
+			i:       0,
+			args:    []Arg{{Name: "u", Got: user{Name: "a", age: 1}, Want: user{Name: "a", age: 1}}},
+		},
+		{
+			name:    "not called",
+			callers: []string{"a_test.go:1"},
+			i:       1,
+			want:    []string{"M.Get was called 1 times, want call #1"},
+		},
+		{
+			name:    "mismatch",
+			callers: []string{"a_test.go:1"},
+			i:       0,
+			args: []Arg{
+				{Name: "key", Got: "k", Want: "k"},
+				{Name: "u", Got: user{Name: "a", age: 1}, Want: user{Name: "a", age: 2}},
+			},
+			wantSub: "M.Get call #0 at a_test.go:1: argument u mismatch (-want +got):\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			Args(r, "M.Get", tt.callers, tt.i, tt.args)
+			if tt.wantSub != "" {
+				if len(r.errors) != 1 || !strings.HasPrefix(r.errors[0], tt.wantSub) || !strings.Contains(r.errors[0], "age") {
+					t.Errorf("Args() errors = %q, want a diff of age prefixed with %q", r.errors, tt.wantSub)
+				}
+				return
+			}
+			if d := Diff(tt.want, r.errors); d != "" {
+				t.Errorf("Args() errors mismatch (-want +got):\n%s", d)
+			}
+		})
+	}
+}